@@ -2,15 +2,40 @@ package api
 
 import (
 	db "bank/db/sqlc"
+	"bank/util"
 	"database/sql"
+	"encoding/json"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 )
 
+// An account with its balance also formatted in the decimal places of its currency.
+// The available balance excludes funds reserved by pending holds
+type accountResponse struct {
+	db.Account
+	AvailableBalance int64      `json:"available_balance"`
+	FormattedBalance util.Money `json:"formatted_balance"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		Account:          account,
+		AvailableBalance: account.Balance - account.HeldAmount,
+		FormattedBalance: util.NewMoney(account.Balance, account.Currency),
+	}
+}
+
+func newAccountsResponse(accounts []db.Account) []accountResponse {
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = newAccountResponse(account)
+	}
+	return rsp
+}
+
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
@@ -20,13 +45,34 @@ func (server *Server) createAccount(ctx *gin.Context) (err error) {
 		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
 	}
 
-	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
-		Currency: req.Currency,
-		Balance:  0,
+	idempotency, err := idempotencyParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	stored, err := server.storedIdempotentResponse(ctx, idempotency)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		var account db.Account
+		if err = json.Unmarshal(stored.Response, &account); err != nil {
+			return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"accountID": newAccountResponse(account)})
+		return
+	}
+
+	payload := authPayload(ctx)
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			Owner:    payload.Username,
+			Currency: req.Currency,
+			Balance:  0,
+		},
+		Idempotency: idempotency,
 	}
 
-	accountId, err := server.store.CreateAccount(ctx, arg)
+	accountId, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -39,7 +85,7 @@ func (server *Server) createAccount(ctx *gin.Context) (err error) {
 		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"accountID": accountId})
+	ctx.JSON(http.StatusOK, gin.H{"accountID": newAccountResponse(accountId)})
 	return
 }
 
@@ -61,7 +107,11 @@ func (server *Server) getAccount(ctx *gin.Context) (err error) {
 		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	if err = authorizeAccountAccess(authPayload(ctx), account); err != nil {
+		return err
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 	return
 }
 
@@ -76,15 +126,47 @@ func (server *Server) listAccounts(ctx *gin.Context) (err error) {
 		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
 	}
 
-	arg := db.ListAccountsParams{
+	payload := authPayload(ctx)
+	arg := db.ListAccountsByOwnerParams{
+		Owner:  payload.Username,
 		Limit:  req.PageSize,
 		Offset: (req.PageID - 1) * req.PageSize,
 	}
-	accounts, err := server.store.ListAccounts(ctx, arg)
+	accounts, err := server.store.ListAccountsByOwner(ctx, arg)
 	if err != nil {
 		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	ctx.JSON(http.StatusOK, newAccountsResponse(accounts))
+	return
+}
+
+type setOverdraftLimitRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+func (server *Server) setOverdraftLimit(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req setOverdraftLimitRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	arg := db.SetOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: *req.OverdraftLimit,
+	}
+	account, err := server.store.SetOverdraftLimit(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 	return
 }
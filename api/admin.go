@@ -0,0 +1,141 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Lists the accounts of every user
+func (server *Server) listAllAccounts(ctx *gin.Context) (err error) {
+	var req listAccountsRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	arg := db.ListAccountsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+	accounts, err := server.store.ListAccounts(ctx, arg)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, newAccountsResponse(accounts))
+	return
+}
+
+type userRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type listUserTransfersRequest struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// Lists the transfers sent or received by any account of the user newest first,
+// paginated with the cursors of listAccountTransfers
+func (server *Server) listUserTransfers(ctx *gin.Context) (err error) {
+	var uri userRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req listUserTransfersRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	beforeID, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return err
+	}
+
+	arg := db.ListTransfersByOwnerParams{
+		Owner:    uri.Username,
+		BeforeID: beforeID,
+		Limit:    req.PageSize,
+	}
+	transfers, err := server.store.ListTransfersByOwner(ctx, arg)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	rsp := listAccountTransfersResponse{Transfers: transfers}
+	if len(transfers) > 0 {
+		rsp.NextCursor = nextCursor(len(transfers), req.PageSize, transfers[len(transfers)-1].ID)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+	return
+}
+
+func (server *Server) freezeAccount(ctx *gin.Context) error {
+	return server.setAccountFrozen(ctx, true)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) error {
+	return server.setAccountFrozen(ctx, false)
+}
+
+// Frozen accounts are rejected by transfers in either direction
+func (server *Server) setAccountFrozen(ctx *gin.Context, frozen bool) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	account, err := server.store.SetAccountFrozen(ctx, db.SetAccountFrozenParams{
+		ID:       uri.ID,
+		IsFrozen: frozen,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+	return
+}
+
+type setUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+// Changes the role of a user and revokes their sessions so tokens carrying the old
+// role stop working
+func (server *Server) setUserRole(ctx *gin.Context) (err error) {
+	var uri userRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req setUserRoleRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	user, err := server.store.SetUserRole(ctx, db.SetUserRoleParams{
+		Username: uri.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	sessionIDs, err := server.store.BlockUserSessions(ctx, user.Username)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	for _, sessionID := range sessionIDs {
+		server.revocation.Revoke(sessionID)
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+	return
+}
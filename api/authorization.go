@@ -0,0 +1,52 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"bank/token"
+	"bank/util"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Returns the token payload stored in the context by authMiddleware
+func authPayload(ctx *gin.Context) *token.Payload {
+	return ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+}
+
+// Checks that the account belongs to the authenticated user
+func authorizeAccountOwner(payload *token.Payload, account db.Account) error {
+	if account.Owner != payload.Username {
+		return &ApiError{Status: http.StatusForbidden, Err: "account does not belong to the authenticated user"}
+	}
+	return nil
+}
+
+// Reports whether the user is bank staff allowed to look up any account
+func isStaff(payload *token.Payload) bool {
+	return payload.Role == util.RoleBanker || payload.Role == util.RoleAdmin
+}
+
+// Checks that the account belongs to the authenticated user or that the user is staff
+func authorizeAccountAccess(payload *token.Payload, account db.Account) error {
+	if isStaff(payload) {
+		return nil
+	}
+	return authorizeAccountOwner(payload, account)
+}
+
+// Loads an account the caller may read, see authorizeAccountAccess
+func (server *Server) readableAccount(ctx *gin.Context, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Account{}, &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return db.Account{}, &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	if err = authorizeAccountAccess(authPayload(ctx), account); err != nil {
+		return db.Account{}, err
+	}
+	return account, nil
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type batchTransferItemRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64 `json:"amount" binding:"required,gt=0"`
+	// Optional, defaults to the description of the batch
+	Description string `json:"description" binding:"max=255"`
+}
+
+type createBatchTransferRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	// Every destination account must hold the currency of the source account
+	Currency    string                     `json:"currency" binding:"required,currency"`
+	Mode        string                     `json:"mode" binding:"required,oneof=all_or_nothing best_effort"`
+	Description string                     `json:"description" binding:"max=255"`
+	Items       []batchTransferItemRequest `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// Posts transfers from one of the caller's accounts to many accounts at once, such as
+// a payroll run. An all_or_nothing batch fails with the first item that cannot be
+// posted, a best_effort batch posts the others and reports why each item failed
+func (server *Server) createBatchTransfer(ctx *gin.Context) (err error) {
+	var req createBatchTransferRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	idempotency, err := idempotencyParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	stored, err := server.storedIdempotentResponse(ctx, idempotency)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		ctx.Data(int(stored.Status), gin.MIMEJSON+"; charset=utf-8", stored.Response)
+		return
+	}
+
+	validCh := make(chan validAccountResult)
+	go server.validAccount(ctx, req.FromAccountID, req.Currency, validCh)
+	valid := <-validCh
+	if valid.err != nil {
+		return valid.err
+	}
+	payload := authPayload(ctx)
+	if err = authorizeAccountOwner(payload, valid.account); err != nil {
+		return err
+	}
+
+	arg := db.BatchTransferTxParams{
+		Owner:         payload.Username,
+		FromAccountID: req.FromAccountID,
+		Mode:          req.Mode,
+		Description:   req.Description,
+		Items:         make([]db.BatchTransferItem, len(req.Items)),
+		Idempotency:   idempotency,
+	}
+	for i, item := range req.Items {
+		arg.Items[i] = db.BatchTransferItem{
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+			Description: item.Description,
+		}
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		var itemErr *db.BatchItemError
+		var limitErr *db.LimitExceededError
+		if errors.As(err, &itemErr) || errors.Is(err, db.ErrInsufficientFunds) || errors.As(err, &limitErr) {
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == idempotencyKeyConstraint {
+			return &ApiError{Status: http.StatusConflict, Err: "a request with this Idempotency-Key is already being processed"}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+	return
+}
+
+type getBatchTransferResponse struct {
+	Batch db.TransferBatch       `json:"batch"`
+	Items []db.TransferBatchItem `json:"items"`
+}
+
+// Returns a batch and the outcome of each of its items to the user who submitted it
+// or to bank staff
+func (server *Server) getBatchTransfer(ctx *gin.Context) (err error) {
+	var req getTransferRequest
+	if err = ctx.ShouldBindUri(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	batch, err := server.store.GetTransferBatch(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	payload := authPayload(ctx)
+	if batch.Owner != payload.Username && !isStaff(payload) {
+		return &ApiError{Status: http.StatusForbidden, Err: "batch does not belong to the authenticated user"}
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, getBatchTransferResponse{Batch: batch, Items: items})
+	return
+}
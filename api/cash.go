@@ -0,0 +1,91 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Unique index on the external reference of deposits and withdrawals
+const externalReferenceConstraint = "transfers_external_reference_key"
+
+type cashRequest struct {
+	// Taken from the URI so the Idempotency-Key hash covers it
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Currency  string `json:"currency" binding:"required,currency"`
+	// Reference of the movement outside the bank, each can only be posted once
+	ExternalReference string `json:"external_reference" binding:"required,max=255"`
+	// Optional memo, defaults to the kind of movement and its external reference
+	Description string `json:"description" binding:"max=255"`
+}
+
+// Credits an account with cash or an incoming wire, taken from the clearing account
+func (server *Server) depositFunds(ctx *gin.Context) (err error) {
+	return server.moveCash(ctx, server.store.DepositTx)
+}
+
+// Debits an account for cash or an outgoing wire, paid into the clearing account
+func (server *Server) withdrawFunds(ctx *gin.Context) (err error) {
+	return server.moveCash(ctx, server.store.WithdrawTx)
+}
+
+func (server *Server) moveCash(ctx *gin.Context, post func(ctx context.Context, arg db.CashTxParams) (db.TransferTxResult, error)) error {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	req.AccountID = uri.ID
+
+	idempotency, err := idempotencyParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	stored, err := server.storedIdempotentResponse(ctx, idempotency)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		ctx.Data(int(stored.Status), gin.MIMEJSON+"; charset=utf-8", stored.Response)
+		return nil
+	}
+
+	validCh := make(chan validAccountResult)
+	go server.validAccount(ctx, req.AccountID, req.Currency, validCh)
+	if valid := <-validCh; valid.err != nil {
+		return valid.err
+	}
+
+	result, err := post(ctx, db.CashTxParams{
+		AccountID:         req.AccountID,
+		Amount:            req.Amount,
+		ExternalReference: req.ExternalReference,
+		Description:       req.Description,
+		Idempotency:       idempotency,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		}
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Constraint {
+			case externalReferenceConstraint:
+				return &ApiError{Status: http.StatusConflict, Err: "external_reference was already posted"}
+			case idempotencyKeyConstraint:
+				return &ApiError{Status: http.StatusConflict, Err: "a request with this Idempotency-Key is already being processed"}
+			}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+	return nil
+}
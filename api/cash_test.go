@@ -0,0 +1,188 @@
+package api
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bank/util"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCashAPI(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+	clearing := randomAccount(db.SystemUser)
+	clearing.ID = account.ID + 1
+	clearing.Currency = util.USD
+	clearing.IsClearing = true
+
+	testCases := []struct {
+		name          string
+		action        string
+		accountID     int64
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "DepositOK",
+			action:    "deposit",
+			accountID: account.ID,
+			body:      gin.H{"amount": 500, "currency": util.USD, "external_reference": "WIRE-1"},
+			role:      util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Eq(db.CashTxParams{AccountID: account.ID, Amount: 500, ExternalReference: "WIRE-1"})).
+					Times(1).
+					Return(db.TransferTxResult{FromAccountID: clearing.ID, ToAccountID: account.ID, Amount: 500, ToBalance: account.Balance + 500}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.TransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account.ID, got.ToAccountID)
+				require.Equal(t, account.Balance+500, got.ToBalance)
+			},
+		},
+		{
+			name:      "WithdrawOK",
+			action:    "withdraw",
+			accountID: account.ID,
+			body:      gin.H{"amount": 500, "currency": util.USD, "external_reference": "SLIP-1"},
+			role:      util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Eq(db.CashTxParams{AccountID: account.ID, Amount: 500, ExternalReference: "SLIP-1"})).
+					Times(1).
+					Return(db.TransferTxResult{FromAccountID: account.ID, ToAccountID: clearing.ID, Amount: 500}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "WithdrawInsufficientFunds",
+			action:    "withdraw",
+			accountID: account.ID,
+			body:      gin.H{"amount": 500, "currency": util.USD, "external_reference": "SLIP-2"},
+			role:      util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:      "DuplicateExternalReference",
+			action:    "deposit",
+			accountID: account.ID,
+			body:      gin.H{"amount": 500, "currency": util.USD, "external_reference": "WIRE-1"},
+			role:      util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &pq.Error{Code: "23505", Constraint: externalReferenceConstraint})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "CurrencyMismatch",
+			action:    "deposit",
+			accountID: account.ID,
+			body:      gin.H{"amount": 500, "currency": util.EUR, "external_reference": "WIRE-2"},
+			role:      util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "ClearingAccount",
+			action:    "deposit",
+			accountID: clearing.ID,
+			body:      gin.H{"amount": 500, "currency": util.USD, "external_reference": "WIRE-3"},
+			role:      util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), clearing.ID).Times(1).Return(clearing, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingExternalReference",
+			action:    "deposit",
+			accountID: account.ID,
+			body:      gin.H{"amount": 500, "currency": util.USD},
+			role:      util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "CustomerForbidden",
+			action:    "deposit",
+			accountID: account.ID,
+			body:      gin.H{"amount": 500, "currency": util.USD, "external_reference": "WIRE-4"},
+			role:      util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/admin/accounts/%d/%s", tc.accountID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			addAuthorization(t, request, server.tokenMaker, util.RandomOwner(), tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
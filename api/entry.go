@@ -0,0 +1,167 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"bank/statement"
+	"bank/util"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type listAccountEntriesRequest struct {
+	StartTime time.Time `form:"start_time" binding:"required"`
+	EndTime   time.Time `form:"end_time" binding:"required"`
+	PageSize  int32     `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor    string    `form:"cursor"`
+}
+
+type accountStatementResponse struct {
+	AccountID      int64     `json:"account_id"`
+	Currency       string    `json:"currency"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	// The balances formatted in the decimal places of the currency
+	FormattedOpeningBalance util.Money                 `json:"formatted_opening_balance"`
+	FormattedClosingBalance util.Money                 `json:"formatted_closing_balance"`
+	Entries                 []db.ListAccountEntriesRow `json:"entries"`
+	NextCursor              string                     `json:"next_cursor,omitempty"`
+}
+
+// Returns the entries of an account between start_time and end_time, oldest first, with
+// the balance after each entry and the balances at the start and end of the period
+func (server *Server) listAccountEntries(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req listAccountEntriesRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return &ApiError{Status: http.StatusBadRequest, Err: "start_time must be before end_time"}
+	}
+	afterID, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return err
+	}
+
+	account, err := server.readableAccount(ctx, uri.ID)
+	if err != nil {
+		return err
+	}
+
+	opening, err := server.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        req.StartTime,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	closing, err := server.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        req.EndTime,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID:   account.ID,
+		CreatedFrom: req.StartTime,
+		CreatedTo:   req.EndTime,
+		AfterID:     afterID,
+		Limit:       req.PageSize,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	rsp := accountStatementResponse{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+		Entries:        entries,
+
+		FormattedOpeningBalance: util.NewMoney(opening, account.Currency),
+		FormattedClosingBalance: util.NewMoney(closing, account.Currency),
+	}
+	if len(entries) > 0 {
+		rsp.NextCursor = nextCursor(len(entries), req.PageSize, entries[len(entries)-1].ID)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+	return
+}
+
+type exportStatementRequest struct {
+	Format string    `form:"format" binding:"required,oneof=csv ofx qif"`
+	From   time.Time `form:"from" binding:"required"`
+	To     time.Time `form:"to" binding:"required"`
+}
+
+// Streams the statement of an account for a period as a CSV, OFX or QIF attachment
+func (server *Server) exportStatement(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req exportStatementRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	if !req.From.Before(req.To) {
+		return &ApiError{Status: http.StatusBadRequest, Err: "from must be before to"}
+	}
+
+	account, err := server.readableAccount(ctx, uri.ID)
+	if err != nil {
+		return err
+	}
+
+	opening, err := server.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        req.From,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	closing, err := server.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        req.To,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	stmt := statement.Statement{
+		Account:        account,
+		Start:          req.From,
+		End:            req.To,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+	}
+	ctx.Header("Content-Type", statement.ContentType(req.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statement.FileName(stmt, req.Format)))
+	ctx.Status(http.StatusOK)
+
+	if err = statement.Export(ctx, server.store, stmt, req.Format, ctx.Writer); err != nil {
+		// The status line is already sent once the body started streaming
+		if ctx.Writer.Written() {
+			ctx.Error(err)
+			ctx.Abort()
+			return nil
+		}
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	return
+}
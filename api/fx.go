@@ -0,0 +1,104 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"bank/fx"
+	"bank/util"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Validity of a quote when FX_QUOTE_DURATION is not set
+const defaultFXQuoteDuration = time.Minute
+
+type createFxQuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+	Amount       int64  `json:"amount" binding:"required,gt=0"`
+}
+
+// Locks the current rate for a conversion until the quote expires. The returned
+// quote ID can be passed to POST /transfer once
+func (server *Server) createFxQuote(ctx *gin.Context) (err error) {
+	if server.rates == nil {
+		return &ApiError{Status: http.StatusNotFound, Err: "currency conversion is not enabled"}
+	}
+	var req createFxQuoteRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	rate, toAmount, err := server.convertAmount(ctx, req.Amount, req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		return err
+	}
+
+	duration := server.config.FXQuoteDuration
+	if duration <= 0 {
+		duration = defaultFXQuoteDuration
+	}
+	quote, err := server.store.CreateFxQuote(ctx, db.CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     authPayload(ctx).Username,
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		Amount:       req.Amount,
+		ToAmount:     toAmount,
+		ExchangeRate: rate.String(),
+		ExpiresAt:    time.Now().Add(duration),
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, quote)
+	return
+}
+
+// Converts an amount between the minor units of two currencies at the current rate
+func (server *Server) convertAmount(ctx *gin.Context, amount int64, fromCurrency string, toCurrency string) (fx.Rate, int64, error) {
+	rate, err := server.rates.Rate(ctx, fromCurrency, toCurrency)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateNotFound) {
+			return fx.Rate{}, 0, &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		}
+		return fx.Rate{}, 0, &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	toAmount, err := rate.ConvertMinor(amount, util.MinorUnits(fromCurrency), util.MinorUnits(toCurrency))
+	if err != nil {
+		return fx.Rate{}, 0, &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	if toAmount <= 0 {
+		return fx.Rate{}, 0, &ApiError{Status: http.StatusUnprocessableEntity, Err: "amount is too small to convert"}
+	}
+	return rate, toAmount, nil
+}
+
+// Checks that a quote belongs to the caller, can still be used and matches the transfer
+func (server *Server) validQuote(ctx *gin.Context, quoteID uuid.UUID, from db.Account, to db.Account, amount int64) error {
+	quote, err := server.store.GetFxQuote(ctx, quoteID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: "fx quote not found"}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	if quote.Username != authPayload(ctx).Username {
+		return &ApiError{Status: http.StatusNotFound, Err: "fx quote not found"}
+	}
+	if quote.UsedAt.Valid {
+		return &ApiError{Status: http.StatusUnprocessableEntity, Err: "fx quote was already used"}
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		return &ApiError{Status: http.StatusUnprocessableEntity, Err: "fx quote has expired"}
+	}
+	if quote.FromCurrency != from.Currency || quote.ToCurrency != to.Currency || quote.Amount != amount {
+		return &ApiError{Status: http.StatusBadRequest, Err: "transfer does not match the fx quote"}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Longest validity of a hold when HOLD_DURATION is not set
+const defaultHoldDuration = 7 * 24 * time.Hour
+
+type createHoldRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1,nefield=FromAccountID"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	// Optional, defaults to and may not exceed HOLD_DURATION from now
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Optional memo shown on the statements of both accounts
+	Description string `json:"description" binding:"max=255"`
+}
+
+// Reserves funds of the caller's account for a later capture or void. Holds are
+// limited to accounts of the same currency
+func (server *Server) createHold(ctx *gin.Context) (err error) {
+	var req createHoldRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	maxDuration := server.config.HoldDuration
+	if maxDuration <= 0 {
+		maxDuration = defaultHoldDuration
+	}
+	now := time.Now()
+	expiresAt := now.Add(maxDuration)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) || req.ExpiresAt.After(expiresAt) {
+			return &ApiError{Status: http.StatusBadRequest, Err: "expires_at must be in the future and within " + maxDuration.String()}
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	idempotency, err := idempotencyParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	stored, err := server.storedIdempotentResponse(ctx, idempotency)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		ctx.Data(int(stored.Status), gin.MIMEJSON+"; charset=utf-8", stored.Response)
+		return
+	}
+
+	validFromCh := make(chan validAccountResult)
+	validToCh := make(chan validAccountResult)
+	go server.validAccount(ctx, req.FromAccountID, req.Currency, validFromCh)
+	go server.validAccount(ctx, req.ToAccountID, req.Currency, validToCh)
+
+	validFrom, validTo := <-validFromCh, <-validToCh
+	if validFrom.err != nil {
+		return validFrom.err
+	}
+	if validTo.err != nil {
+		return validTo.err
+	}
+	if err = authorizeAccountOwner(authPayload(ctx), validFrom.account); err != nil {
+		return err
+	}
+
+	result, err := server.store.HoldTx(ctx, db.HoldTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		ExpiresAt:     expiresAt,
+		Description:   req.Description,
+		Idempotency:   idempotency,
+	})
+	if err != nil {
+		var limitErr *db.LimitExceededError
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrApprovalRequired) || errors.As(err, &limitErr) {
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == idempotencyKeyConstraint {
+			return &ApiError{Status: http.StatusConflict, Err: "a request with this Idempotency-Key is already being processed"}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+	return
+}
+
+type captureHoldRequest struct {
+	// Optional, captures the whole hold when omitted
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// Posts a pending hold. Only the payee or staff may capture it, the payer can only void it
+func (server *Server) captureHold(ctx *gin.Context) (err error) {
+	hold, err := server.loadHold(ctx)
+	if err != nil {
+		return err
+	}
+	if err = server.authorizeHoldPayee(ctx, hold); err != nil {
+		return err
+	}
+	var req captureHoldRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	result, err := server.store.CaptureTx(ctx, db.CaptureTxParams{
+		TransferID: hold.ID,
+		Amount:     req.Amount,
+		Now:        time.Now(),
+	})
+	if err != nil {
+		return holdError(err)
+	}
+
+	ctx.JSON(http.StatusOK, result)
+	return
+}
+
+// Cancels a pending hold and releases its funds. Either party of the hold or staff may void it
+func (server *Server) voidHold(ctx *gin.Context) (err error) {
+	hold, err := server.loadHold(ctx)
+	if err != nil {
+		return err
+	}
+	if err = server.authorizeTransferAccess(ctx, hold); err != nil {
+		return err
+	}
+
+	transfer, err := server.store.VoidTx(ctx, hold.ID)
+	if err != nil {
+		return holdError(err)
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+	return
+}
+
+// Loads the transfer of the URI, the caller checks who may settle it
+func (server *Server) loadHold(ctx *gin.Context) (db.Transfer, error) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		return db.Transfer{}, &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Transfer{}, &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return db.Transfer{}, &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	return transfer, nil
+}
+
+// Checks that the caller is staff or owns the destination account of the hold
+func (server *Server) authorizeHoldPayee(ctx *gin.Context, hold db.Transfer) error {
+	payload := authPayload(ctx)
+	if isStaff(payload) {
+		return nil
+	}
+	payee, err := server.store.GetAccount(ctx, hold.ToAccountID)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	if payee.Owner != payload.Username {
+		return &ApiError{Status: http.StatusForbidden, Err: "only the payee of the hold may capture it"}
+	}
+	return nil
+}
+
+func holdError(err error) error {
+	switch {
+	case errors.Is(err, db.ErrTransferNotPending), errors.Is(err, db.ErrHoldExpired), errors.Is(err, db.ErrHoldAccountFrozen):
+		return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+	case errors.Is(err, db.ErrCaptureExceedsHold):
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	case err == sql.ErrNoRows:
+		return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+	}
+	return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+}
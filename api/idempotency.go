@@ -0,0 +1,81 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotencyKeyConstraint = "idempotency_keys_pkey"
+)
+
+// Builds the idempotency parameters when the client sent an Idempotency-Key header.
+// The request hash covers the route and the bound request so a key cannot be reused
+// for a different operation
+func idempotencyParams(ctx *gin.Context, req interface{}) (*db.IdempotencyParams, error) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, &ApiError{Status: http.StatusBadRequest, Err: "Idempotency-Key header is too long"}
+	}
+
+	hash, err := requestHash(ctx.Request.Method, ctx.FullPath(), req)
+	if err != nil {
+		return nil, &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	return &db.IdempotencyParams{
+		Key:         key,
+		Username:    authPayload(ctx).Username,
+		RequestHash: hash,
+	}, nil
+}
+
+// Hashes the method, route and JSON encoding of the bound request
+func requestHash(method string, route string, req interface{}) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + route + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Looks up an earlier request made by the caller with the same key. Returns the stored
+// response and status for an identical retry, and a 409 when the key was used for another request
+func (server *Server) storedIdempotentResponse(ctx *gin.Context, params *db.IdempotencyParams) (*db.IdempotencyKey, error) {
+	if params == nil {
+		return nil, nil
+	}
+
+	stored, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: params.Username,
+		Key:      params.Key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	if stored.RequestHash != params.RequestHash {
+		return nil, &ApiError{Status: http.StatusConflict, Err: "Idempotency-Key was already used for a different request"}
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	return &stored, nil
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type postingLegRequest struct {
+	AccountID int64 `json:"account_id" binding:"required,min=1"`
+	// Negative for a debit and positive for a credit
+	Amount      int64  `json:"amount" binding:"required"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+type createLedgerTransactionRequest struct {
+	Description string              `json:"description" binding:"max=255"`
+	Legs        []postingLegRequest `json:"legs" binding:"required,min=2,max=50,dive"`
+}
+
+// Posts a multi-leg transaction atomically, such as a payment split across several
+// recipients. The legs must sum to zero per currency and only accounts of the caller
+// may be debited
+func (server *Server) createLedgerTransaction(ctx *gin.Context) (err error) {
+	var req createLedgerTransactionRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	sums := make(map[string]int64)
+	for _, leg := range req.Legs {
+		sums[leg.Currency] += leg.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return &ApiError{Status: http.StatusBadRequest, Err: fmt.Sprintf("%s legs sum to %d, legs must sum to zero per currency", currency, sum)}
+		}
+	}
+
+	idempotency, err := idempotencyParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	stored, err := server.storedIdempotentResponse(ctx, idempotency)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		ctx.Data(int(stored.Status), gin.MIMEJSON+"; charset=utf-8", stored.Response)
+		return
+	}
+
+	validChs := make([]chan validAccountResult, len(req.Legs))
+	for i, leg := range req.Legs {
+		validChs[i] = make(chan validAccountResult)
+		go server.validAccount(ctx, leg.AccountID, leg.Currency, validChs[i])
+	}
+	payload := authPayload(ctx)
+	arg := db.PostTxParams{
+		Owner:       payload.Username,
+		Description: req.Description,
+		Legs:        make([]db.PostingLeg, len(req.Legs)),
+		Idempotency: idempotency,
+	}
+	// Every channel is drained so no lookup is left blocked
+	var invalid error
+	for i, leg := range req.Legs {
+		valid := <-validChs[i]
+		if invalid != nil {
+			continue
+		}
+		if valid.err != nil {
+			invalid = valid.err
+			continue
+		}
+		if leg.Amount < 0 {
+			invalid = authorizeAccountOwner(payload, valid.account)
+		}
+		arg.Legs[i] = db.PostingLeg{
+			AccountID:   leg.AccountID,
+			Amount:      leg.Amount,
+			Description: leg.Description,
+		}
+	}
+	if invalid != nil {
+		return invalid
+	}
+
+	result, err := server.store.PostTx(ctx, arg)
+	if err != nil {
+		var limitErr *db.LimitExceededError
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrApprovalRequired), errors.As(err, &limitErr):
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		case errors.Is(err, db.ErrInvalidPosting), errors.Is(err, db.ErrUnbalancedPosting):
+			return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+		case errors.Is(err, db.ErrPostingAccountNotFound):
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == idempotencyKeyConstraint {
+			return &ApiError{Status: http.StatusConflict, Err: "a request with this Idempotency-Key is already being processed"}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+	return
+}
+
+type getLedgerTransactionResponse struct {
+	Transaction db.LedgerTransaction `json:"transaction"`
+	Entries     []db.Entry           `json:"entries"`
+}
+
+// Returns a multi-leg transaction and its entries to the user who posted it or to
+// bank staff
+func (server *Server) getLedgerTransaction(ctx *gin.Context) (err error) {
+	var req getTransferRequest
+	if err = ctx.ShouldBindUri(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	transaction, err := server.store.GetLedgerTransaction(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	payload := authPayload(ctx)
+	if transaction.Owner != payload.Username && !isStaff(payload) {
+		return &ApiError{Status: http.StatusForbidden, Err: "transaction does not belong to the authenticated user"}
+	}
+
+	entries, err := server.store.ListLedgerTransactionEntries(ctx, sql.NullInt64{Int64: transaction.ID, Valid: true})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, getLedgerTransactionResponse{Transaction: transaction, Entries: entries})
+	return
+}
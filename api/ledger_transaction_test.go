@@ -0,0 +1,281 @@
+package api
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bank/util"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateLedgerTransactionAPI(t *testing.T) {
+	user1, _ := randomUser()
+	user2, _ := randomUser()
+
+	payer := randomAccount(user1.Username)
+	payer.Currency = util.USD
+	payee1 := randomAccount(user2.Username)
+	payee1.ID = payer.ID + 1
+	payee1.Currency = util.USD
+	payee2 := randomAccount(user2.Username)
+	payee2.ID = payer.ID + 2
+	payee2.Currency = util.USD
+
+	split := []gin.H{
+		{"account_id": payer.ID, "amount": -100, "currency": util.USD},
+		{"account_id": payee1.ID, "amount": 60, "currency": util.USD, "description": "Your share"},
+		{"account_id": payee2.ID, "amount": 40, "currency": util.USD},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			body:     gin.H{"description": "Dinner", "legs": split},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(accountByID(payer, payee1, payee2))
+				arg := db.PostTxParams{
+					Owner:       user1.Username,
+					Description: "Dinner",
+					Legs: []db.PostingLeg{
+						{AccountID: payer.ID, Amount: -100},
+						{AccountID: payee1.ID, Amount: 60, Description: "Your share"},
+						{AccountID: payee2.ID, Amount: 40},
+					},
+				}
+				store.EXPECT().
+					PostTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.PostTxResult{Transaction: db.LedgerTransaction{ID: 5, Owner: user1.Username}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var result db.PostTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+				require.Equal(t, int64(5), result.Transaction.ID)
+			},
+		},
+		{
+			name:     "DebitNotOwned",
+			body:     gin.H{"legs": split},
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(accountByID(payer, payee1, payee2))
+				store.EXPECT().PostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Unbalanced",
+			body: gin.H{"legs": []gin.H{
+				{"account_id": payer.ID, "amount": -100, "currency": util.USD},
+				{"account_id": payee1.ID, "amount": 90, "currency": util.USD},
+			}},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().PostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SingleLeg",
+			body: gin.H{"legs": []gin.H{
+				{"account_id": payer.ID, "amount": 100, "currency": util.USD},
+			}},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().PostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{"legs": []gin.H{
+				{"account_id": payer.ID, "amount": -100, "currency": util.EUR},
+				{"account_id": payee1.ID, "amount": 100, "currency": util.EUR},
+			}},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(accountByID(payer, payee1))
+				store.EXPECT().PostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			body:     gin.H{"legs": split},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(accountByID(payer, payee1, payee2))
+				store.EXPECT().PostTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PostTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "ApprovalRequired",
+			body:     gin.H{"legs": split},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(accountByID(payer, payee1, payee2))
+				store.EXPECT().
+					PostTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PostTxResult{}, fmt.Errorf("%w %d", db.ErrApprovalRequired, payer.ID))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "LimitExceeded",
+			body:     gin.H{"legs": split},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				limitErr := &db.LimitExceededError{
+					Limit: db.Limit{
+						AccountID: sql.NullInt64{Int64: payer.ID, Valid: true},
+						Kind:      db.LimitSingleTransfer,
+						Maximum:   50,
+					},
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(accountByID(payer, payee1, payee2))
+				store.EXPECT().PostTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PostTxResult{}, limitErr)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "single_transfer limit of account")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transactions", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			addAuthorization(t, request, server.tokenMaker, tc.username, util.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetLedgerTransactionAPI(t *testing.T) {
+	user1, _ := randomUser()
+	user2, _ := randomUser()
+
+	transaction := db.LedgerTransaction{ID: 5, Owner: user1.Username, Description: "Dinner"}
+	link := sql.NullInt64{Int64: transaction.ID, Valid: true}
+	entries := []db.Entry{
+		{ID: 1, AccountID: 10, Amount: -100, LedgerTransactionID: link},
+		{ID: 2, AccountID: 11, Amount: 100, LedgerTransactionID: link},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLedgerTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().ListLedgerTransactionEntries(gomock.Any(), gomock.Eq(link)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response getLedgerTransactionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, transaction, response.Transaction)
+				require.Equal(t, entries, response.Entries)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: user2.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLedgerTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().ListLedgerTransactionEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Staff",
+			username: user2.Username,
+			role:     util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLedgerTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().ListLedgerTransactionEntries(gomock.Any(), gomock.Eq(link)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/transactions/%d", transaction.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type limitKindRequest struct {
+	Kind string `uri:"kind" binding:"required,oneof=single_transfer daily_total monthly_total hourly_count"`
+}
+
+type setLimitRequest struct {
+	// An amount in minor units, or a number of transfers for hourly_count
+	Maximum *int64 `json:"maximum" binding:"required,min=0"`
+}
+
+// Sets a limit on the transfers sent from an account, replacing any limit of that kind
+func (server *Server) setAccountLimit(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var kind limitKindRequest
+	if err = ctx.ShouldBindUri(&kind); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req setLimitRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	limit, err := server.store.SetAccountLimit(ctx, db.SetAccountLimitParams{
+		AccountID: sql.NullInt64{Int64: uri.ID, Valid: true},
+		Kind:      kind.Kind,
+		Maximum:   *req.Maximum,
+	})
+	if err != nil {
+		return limitError(err)
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+	return
+}
+
+type setUserLimitRequest struct {
+	Currency string `json:"currency" binding:"required,currency"`
+	// An amount in minor units, or a number of transfers for hourly_count
+	Maximum *int64 `json:"maximum" binding:"required,min=0"`
+}
+
+// Sets a limit on the transfers sent from all accounts of a user in one currency,
+// replacing any limit of that kind
+func (server *Server) setUserLimit(ctx *gin.Context) (err error) {
+	var uri userRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var kind limitKindRequest
+	if err = ctx.ShouldBindUri(&kind); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req setUserLimitRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	limit, err := server.store.SetUserLimit(ctx, db.SetUserLimitParams{
+		Owner:    sql.NullString{String: uri.Username, Valid: true},
+		Currency: sql.NullString{String: req.Currency, Valid: true},
+		Kind:     kind.Kind,
+		Maximum:  *req.Maximum,
+	})
+	if err != nil {
+		return limitError(err)
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+	return
+}
+
+// Setting a limit on an unknown account or user violates a foreign key
+func limitError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+		return &ApiError{Status: http.StatusNotFound, Err: pqErr.Error()}
+	}
+	return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+}
+
+// Removes a limit of an account or a user
+func (server *Server) deleteLimit(ctx *gin.Context) (err error) {
+	var req getTransferRequest
+	if err = ctx.ShouldBindUri(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	limit, err := server.store.DeleteLimit(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+	return
+}
+
+type listAccountLimitsResponse struct {
+	AccountID int64           `json:"account_id"`
+	Currency  string          `json:"currency"`
+	Limits    []db.LimitUsage `json:"limits"`
+}
+
+// Lists the limits on transfers out of an account with the allowance left of each
+func (server *Server) listAccountLimits(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	account, err := server.readableAccount(ctx, uri.ID)
+	if err != nil {
+		return err
+	}
+
+	usages, err := db.ListLimitUsage(ctx, server.store, account, time.Now())
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, listAccountLimitsResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Limits:    usages,
+	})
+	return
+}
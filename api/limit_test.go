@@ -0,0 +1,351 @@
+package api
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bank/util"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListAccountLimitsAPI(t *testing.T) {
+	user1, _ := randomUser()
+	user2, _ := randomUser()
+	account := randomAccount(user1.Username)
+
+	accountLimit := db.Limit{
+		ID:        1,
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		Kind:      db.LimitDailyTotal,
+		Maximum:   1000,
+	}
+	singleLimit := db.Limit{
+		ID:        2,
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		Kind:      db.LimitSingleTransfer,
+		Maximum:   500,
+	}
+	userLimit := db.Limit{
+		ID:       3,
+		Owner:    sql.NullString{String: user1.Username, Valid: true},
+		Currency: sql.NullString{String: account.Currency, Valid: true},
+		Kind:     db.LimitHourlyCount,
+		Maximum:  3,
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListAccountLimits(gomock.Any(), gomock.Eq(db.ListAccountLimitsParams{
+						AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+						Owner:     sql.NullString{String: account.Owner, Valid: true},
+						Currency:  sql.NullString{String: account.Currency, Valid: true},
+					})).
+					Times(1).
+					Return([]db.Limit{accountLimit, singleLimit, userLimit}, nil)
+				// Totals are read once for the account and once for the owner
+				store.EXPECT().
+					GetOutgoingTransferTotals(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ any, arg db.GetOutgoingTransferTotalsParams) (db.GetOutgoingTransferTotalsRow, error) {
+						require.True(t, arg.HourStart.Before(time.Now()))
+						require.False(t, arg.MonthStart.After(arg.DayStart))
+						if arg.AccountID.Valid {
+							require.Equal(t, account.ID, arg.AccountID.Int64)
+							return db.GetOutgoingTransferTotalsRow{DailyTotal: 400, MonthlyTotal: 900, HourlyCount: 1}, nil
+						}
+						require.Equal(t, user1.Username, arg.Owner.String)
+						require.Equal(t, account.Currency, arg.Currency.String)
+						return db.GetOutgoingTransferTotalsRow{DailyTotal: 700, MonthlyTotal: 1500, HourlyCount: 4}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response listAccountLimitsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, account.ID, response.AccountID)
+				require.Len(t, response.Limits, 3)
+
+				require.Equal(t, int64(400), response.Limits[0].Used)
+				require.Equal(t, int64(600), response.Limits[0].Remaining)
+				require.Equal(t, int64(0), response.Limits[1].Used)
+				require.Equal(t, int64(500), response.Limits[1].Remaining)
+				require.Equal(t, int64(4), response.Limits[2].Used)
+				require.Equal(t, int64(0), response.Limits[2].Remaining)
+			},
+		},
+		{
+			name:     "NoLimits",
+			username: user1.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountLimits(gomock.Any(), gomock.Any()).Times(1).Return([]db.Limit{}, nil)
+				store.EXPECT().GetOutgoingTransferTotals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"limits":[]`)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: user2.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d/limits", account.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestSetLimitAPI(t *testing.T) {
+	admin, _ := randomUser()
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		url           string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "AccountLimit",
+			url:  fmt.Sprintf("/admin/accounts/%d/limits/%s", account.ID, db.LimitDailyTotal),
+			body: gin.H{"maximum": 1000},
+			role: util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SetAccountLimitParams{
+					AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+					Kind:      db.LimitDailyTotal,
+					Maximum:   1000,
+				}
+				store.EXPECT().
+					SetAccountLimit(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Limit{ID: 1, AccountID: arg.AccountID, Kind: arg.Kind, Maximum: arg.Maximum}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var limit db.Limit
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &limit))
+				require.Equal(t, int64(1000), limit.Maximum)
+			},
+		},
+		{
+			name: "ZeroMaximum",
+			url:  fmt.Sprintf("/admin/accounts/%d/limits/%s", account.ID, db.LimitHourlyCount),
+			body: gin.H{"maximum": 0},
+			role: util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetAccountLimit(gomock.Any(), gomock.Any()).Times(1).Return(db.Limit{ID: 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			url:  fmt.Sprintf("/admin/accounts/%d/limits/%s", account.ID, db.LimitDailyTotal),
+			body: gin.H{"maximum": 1000},
+			role: util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetAccountLimit(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Limit{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidKind",
+			url:  fmt.Sprintf("/admin/accounts/%d/limits/weekly_total", account.ID),
+			body: gin.H{"maximum": 1000},
+			role: util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetAccountLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeMaximum",
+			url:  fmt.Sprintf("/admin/accounts/%d/limits/%s", account.ID, db.LimitDailyTotal),
+			body: gin.H{"maximum": -1},
+			role: util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetAccountLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Banker",
+			url:  fmt.Sprintf("/admin/accounts/%d/limits/%s", account.ID, db.LimitDailyTotal),
+			body: gin.H{"maximum": 1000},
+			role: util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetAccountLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "UserLimit",
+			url:  fmt.Sprintf("/admin/users/%s/limits/%s", user.Username, db.LimitMonthlyTotal),
+			body: gin.H{"maximum": 50000, "currency": util.USD},
+			role: util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SetUserLimitParams{
+					Owner:    sql.NullString{String: user.Username, Valid: true},
+					Currency: sql.NullString{String: util.USD, Valid: true},
+					Kind:     db.LimitMonthlyTotal,
+					Maximum:  50000,
+				}
+				store.EXPECT().
+					SetUserLimit(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Limit{ID: 2, Owner: arg.Owner, Currency: arg.Currency, Kind: arg.Kind, Maximum: arg.Maximum}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UserLimitWithoutCurrency",
+			url:  fmt.Sprintf("/admin/users/%s/limits/%s", user.Username, db.LimitMonthlyTotal),
+			body: gin.H{"maximum": 50000},
+			role: util.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetUserLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			addAuthorization(t, request, server.tokenMaker, admin.Username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteLimitAPI(t *testing.T) {
+	admin, _ := randomUser()
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteLimit(gomock.Any(), gomock.Eq(int64(3))).Times(1).Return(db.Limit{ID: 3}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteLimit(gomock.Any(), gomock.Eq(int64(3))).Times(1).Return(db.Limit{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, "/admin/limits/3", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, admin.Username, util.RoleAdmin, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
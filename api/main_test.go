@@ -1,6 +1,7 @@
 package api
 
 import (
+	mockdb "bank/db/mock"
 	db "bank/db/sqlc"
 	"bank/util"
 	"os"
@@ -9,16 +10,26 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func NewTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenKey:      util.RandomString(32),
-		TokenDuration: time.Minute,
+		TokenKey:             util.RandomString(32),
+		TokenDuration:        time.Minute,
+		RefreshTokenDuration: time.Hour,
 	}
 	server, err := NewServer(config, store)
 	require.NoError(t, err)
 
+	// Sessions of test tokens stay active unless a test sets its own expectation first
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetSessionBlocked(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+	}
+
 	return server
 }
 
@@ -8,7 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+const authorizationPayloadKey = "auth_payload"
+
+func authMiddleware(tokenMaker token.Maker, revocation revocationChecker) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader("Authorization")
 		if !strings.HasPrefix(authorizationHeader, "Bearer ") {
@@ -24,8 +26,41 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(err.Status, err)
 			return
 		}
+		// Refresh tokens outlive access tokens and must not be usable in their place
+		if payload.Type != token.AccessToken {
+			err := &ApiError{Status: http.StatusUnauthorized, Err: "not an access token"}
+			ctx.AbortWithStatusJSON(err.Status, err)
+			return
+		}
+
+		revoked, err := revocation.IsRevoked(ctx, payload.SessionID)
+		if err != nil {
+			err := &ApiError{Status: http.StatusInternalServerError, Err: "cannot check token revocation"}
+			ctx.AbortWithStatusJSON(err.Status, err)
+			return
+		}
+		if revoked {
+			err := &ApiError{Status: http.StatusUnauthorized, Err: "token has been revoked"}
+			ctx.AbortWithStatusJSON(err.Status, err)
+			return
+		}
 
-		ctx.Set("auth_payload", payload)
+		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
-}
\ No newline at end of file
+}
+
+// Only lets through users whose token carries one of the given roles
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := authPayload(ctx)
+		for _, role := range roles {
+			if role == payload.Role {
+				ctx.Next()
+				return
+			}
+		}
+		err := &ApiError{Status: http.StatusForbidden, Err: "insufficient role for this operation"}
+		ctx.AbortWithStatusJSON(err.Status, err)
+	}
+}
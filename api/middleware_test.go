@@ -2,6 +2,8 @@ package api
 
 import (
 	"bank/token"
+	"bank/util"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -9,29 +11,57 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
 type mockTokenMaker struct {
     validToken string
+    sessionID  uuid.UUID
+    tokenType  string
 }
 
-func (m *mockTokenMaker) CreateToken(username string, duration time.Duration) (string, error) {
-    return m.validToken, nil
+func (m *mockTokenMaker) CreateToken(username string, role string, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *token.Payload, error) {
+    return m.validToken, &token.Payload{SessionID: sessionID, Type: tokenType}, nil
 }
 
 func (m *mockTokenMaker) VerifyToken(tokenString string) (*token.Payload, error) {
     if tokenString == m.validToken {
-        return &token.Payload{}, nil
+        return &token.Payload{SessionID: m.sessionID, Type: m.tokenType}, nil
     }
     return nil, fmt.Errorf("invalid token")
 }
 
+type mockRevocationChecker struct {
+    revoked map[uuid.UUID]bool
+}
+
+func (m *mockRevocationChecker) IsRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+    return m.revoked[sessionID], nil
+}
+
+func (m *mockRevocationChecker) Revoke(sessionID uuid.UUID) {
+    m.revoked[sessionID] = true
+}
+
+func addAuthorization(t *testing.T, request *http.Request, tokenMaker token.Maker, username string, role string, duration time.Duration) {
+    accessToken, payload, err := tokenMaker.CreateToken(username, role, token.AccessToken, uuid.New(), duration)
+    require.NoError(t, err)
+    require.NotEmpty(t, accessToken)
+    require.NotEmpty(t, payload)
+
+    request.Header.Set("Authorization", "Bearer "+accessToken)
+}
+
 func TestAuthMiddleware(t *testing.T) {
     gin.SetMode(gin.TestMode)
 
+    revokedSession := uuid.New()
+
     tests := []struct {
         name          string
+        sessionID     uuid.UUID
+        tokenType     string
         setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
         checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
     }{
@@ -63,7 +93,32 @@ func TestAuthMiddleware(t *testing.T) {
             },
         },
         {
-            name: "ValidToken",
+            name:      "RevokedToken",
+            sessionID: revokedSession,
+            tokenType: token.AccessToken,
+            setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+                request.Header.Set("Authorization", "Bearer valid-token")
+            },
+            checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+                require.Equal(t, http.StatusUnauthorized, recorder.Code)
+            },
+        },
+        {
+            name:      "RefreshToken",
+            sessionID: uuid.New(),
+            tokenType: token.RefreshToken,
+            setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+                request.Header.Set("Authorization", "Bearer valid-token")
+            },
+            checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+                require.Equal(t, http.StatusUnauthorized, recorder.Code)
+                require.Contains(t, recorder.Body.String(), "not an access token")
+            },
+        },
+        {
+            name:      "ValidToken",
+            sessionID: uuid.New(),
+            tokenType: token.AccessToken,
             setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
                 request.Header.Set("Authorization", "Bearer valid-token")
             },
@@ -75,10 +130,11 @@ func TestAuthMiddleware(t *testing.T) {
 
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
-            tokenMaker := &mockTokenMaker{validToken: "valid-token"}
+            tokenMaker := &mockTokenMaker{validToken: "valid-token", sessionID: tt.sessionID, tokenType: tt.tokenType}
+            revocation := &mockRevocationChecker{revoked: map[uuid.UUID]bool{revokedSession: true}}
 
             router := gin.New()
-            router.Use(authMiddleware(tokenMaker))
+            router.Use(authMiddleware(tokenMaker, revocation))
             router.GET("/", func(ctx *gin.Context) {
                 ctx.JSON(http.StatusOK, gin.H{"message": "success"})
             })
@@ -92,4 +148,36 @@ func TestAuthMiddleware(t *testing.T) {
             tt.checkResponse(t, recorder)
         })
     }
-}
\ No newline at end of file
+}
+func TestRequireRole(t *testing.T) {
+    tests := []struct {
+        name         string
+        role         string
+        expectedCode int
+    }{
+        {name: "Customer", role: util.RoleCustomer, expectedCode: http.StatusForbidden},
+        {name: "Banker", role: util.RoleBanker, expectedCode: http.StatusOK},
+        {name: "Admin", role: util.RoleAdmin, expectedCode: http.StatusOK},
+        {name: "MissingRole", role: "", expectedCode: http.StatusForbidden},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            router := gin.New()
+            router.Use(func(ctx *gin.Context) {
+                ctx.Set(authorizationPayloadKey, &token.Payload{Username: util.RandomOwner(), Role: tt.role})
+            })
+            router.Use(requireRole(util.RoleBanker, util.RoleAdmin))
+            router.GET("/", func(ctx *gin.Context) {
+                ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+            })
+
+            recorder := httptest.NewRecorder()
+            request, err := http.NewRequest(http.MethodGet, "/", nil)
+            require.NoError(t, err)
+
+            router.ServeHTTP(recorder, request)
+            require.Equal(t, tt.expectedCode, recorder.Code)
+        })
+    }
+}
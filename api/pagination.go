@@ -0,0 +1,53 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Encodes the ID of the last row of a page as an opaque cursor for the next page
+func encodeCursor(lastID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(lastID, 10)))
+}
+
+// Decodes a cursor returned by encodeCursor, an empty cursor starts from the first page
+func decodeCursor(cursor string) (sql.NullInt64, error) {
+	if cursor == "" {
+		return sql.NullInt64{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return sql.NullInt64{}, &ApiError{Status: http.StatusBadRequest, Err: "invalid cursor"}
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return sql.NullInt64{}, &ApiError{Status: http.StatusBadRequest, Err: "invalid cursor"}
+	}
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+// Returns the cursor of the page following rows, or an empty cursor when rows is the last page
+func nextCursor(rowCount int, pageSize int32, lastID int64) string {
+	if rowCount < int(pageSize) {
+		return ""
+	}
+	return encodeCursor(lastID)
+}
+
+// Converts an optional query parameter to its nullable query argument
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+func nullTime(v *time.Time) sql.NullTime {
+	if v == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *v, Valid: true}
+}
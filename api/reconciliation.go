@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Runs the scheduled ledger reconciliation every RECONCILE_INTERVAL, does nothing
+// when it is not set
+func (server *Server) StartReconciliation(ctx context.Context) {
+	server.reconciler.Start(ctx)
+}
+
+// Returns the report of the last reconciliation run
+func (server *Server) getReconciliationReport(ctx *gin.Context) (err error) {
+	report, ok := server.reconciler.Latest()
+	if !ok {
+		return &ApiError{Status: http.StatusNotFound, Err: "no reconciliation has run yet"}
+	}
+
+	ctx.JSON(http.StatusOK, report)
+	return
+}
+
+// Reconciles the ledger now and returns the report
+func (server *Server) runReconciliation(ctx *gin.Context) (err error) {
+	report, err := server.reconciler.Run(ctx)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, report)
+	return
+}
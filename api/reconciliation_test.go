@@ -0,0 +1,56 @@
+package api
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bank/reconciliation"
+	"bank/util"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReconciliationAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ListAccountDrift(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListAccountDriftRow{{ID: 1, Currency: util.USD, Balance: 100}}, nil)
+	store.EXPECT().ListOrphanedTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListOrphanedTransfersRow{}, nil)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListUnbalancedTransfersRow{}, nil)
+
+	server := NewTestServer(t, store)
+
+	send := func(method string, role string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, "/admin/reconciliation", nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, util.RandomOwner(), role, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// Nothing to show before the first run
+	require.Equal(t, http.StatusNotFound, send(http.MethodGet, util.RoleAdmin).Code)
+	require.Equal(t, http.StatusForbidden, send(http.MethodPost, util.RoleBanker).Code)
+
+	recorder := send(http.MethodPost, util.RoleAdmin)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var ran reconciliation.Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &ran))
+	require.Len(t, ran.AccountDrifts, 1)
+	require.Equal(t, int64(100), ran.AccountDrifts[0].BalanceDrift)
+
+	recorder = send(http.MethodGet, util.RoleAdmin)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var latest reconciliation.Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &latest))
+	require.Equal(t, ran.AccountDrifts, latest.AccountDrifts)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"bank/util"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type reverseTransferRequest struct {
+	// Taken from the URI so the Idempotency-Key hash covers it
+	TransferID int64 `json:"transfer_id"`
+	// Optional, in the currency of the recipient account. Refunds the whole amount
+	// not yet reversed when omitted
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+	// Optional memo, defaults to naming the reversed transfer
+	Description string `json:"description" binding:"max=255"`
+}
+
+// Refunds a transfer, wholly or partly, with a compensating transfer linked to it.
+// Admins may reverse any transfer, customers only transfers they received and within
+// the approval threshold and limits of their account
+func (server *Server) reverseTransfer(ctx *gin.Context) (err error) {
+	var uri getTransferRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req reverseTransferRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	req.TransferID = uri.ID
+
+	transfer, err := server.store.GetTransfer(ctx, req.TransferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	if err = server.authorizeReversal(ctx, transfer); err != nil {
+		return err
+	}
+
+	idempotency, err := idempotencyParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	stored, err := server.storedIdempotentResponse(ctx, idempotency)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		ctx.Data(int(stored.Status), gin.MIMEJSON+"; charset=utf-8", stored.Response)
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID:  req.TransferID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Admin:       authPayload(ctx).Role == util.RoleAdmin,
+		Idempotency: idempotency,
+	})
+	if err != nil {
+		var limitErr *db.LimitExceededError
+		switch {
+		case errors.Is(err, db.ErrTransferNotReversible), errors.Is(err, db.ErrInsufficientFunds),
+			errors.Is(err, db.ErrApprovalRequired), errors.As(err, &limitErr):
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		case errors.Is(err, db.ErrTransferReversed):
+			return &ApiError{Status: http.StatusConflict, Err: err.Error()}
+		case errors.Is(err, db.ErrReversalExceedsTransfer):
+			return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == idempotencyKeyConstraint {
+			return &ApiError{Status: http.StatusConflict, Err: "a request with this Idempotency-Key is already being processed"}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+	return
+}
+
+// Checks that the caller is an admin or owns the recipient account of the transfer,
+// which must not be frozen. Only admins can reverse deposits and withdrawals
+func (server *Server) authorizeReversal(ctx *gin.Context, transfer db.Transfer) error {
+	payload := authPayload(ctx)
+	if payload.Role == util.RoleAdmin {
+		return nil
+	}
+	if transfer.ExternalReference.Valid {
+		return &ApiError{Status: http.StatusForbidden, Err: "only an admin can reverse a deposit or withdrawal"}
+	}
+
+	account, err := server.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	if account.Owner != payload.Username {
+		return &ApiError{Status: http.StatusForbidden, Err: "only the recipient of a transfer or an admin can reverse it"}
+	}
+	if account.IsFrozen {
+		return &ApiError{Status: http.StatusForbidden, Err: "recipient account is frozen"}
+	}
+	return nil
+}
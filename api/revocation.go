@@ -0,0 +1,125 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRevocationCacheSize = 10000
+	defaultRevocationCacheTTL  = 30 * time.Second
+)
+
+// Decides whether tokens issued for a session are still accepted
+type revocationChecker interface {
+	IsRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error)
+	// Records a session blocked by this server so it takes effect immediately
+	Revoke(sessionID uuid.UUID)
+}
+
+type revocationEntry struct {
+	sessionID uuid.UUID
+	revoked   bool
+	expiresAt time.Time
+}
+
+// Caches the blocked state of sessions read from the DB so authMiddleware does not
+// query the sessions table on every request. Active sessions are re-read after ttl,
+// which bounds how long a session blocked by another server instance stays usable.
+// Revoked sessions never become valid again and only leave the cache on eviction.
+// The least recently used entry is evicted once the cache holds size entries
+type sessionRevocationCache struct {
+	store   db.Store
+	size    int
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[uuid.UUID]*list.Element
+	lru     *list.List
+}
+
+func newSessionRevocationCache(store db.Store, size int, ttl time.Duration) *sessionRevocationCache {
+	if size <= 0 {
+		size = defaultRevocationCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultRevocationCacheTTL
+	}
+	return &sessionRevocationCache{
+		store:   store,
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *sessionRevocationCache) IsRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	if revoked, ok := c.get(sessionID); ok {
+		return revoked, nil
+	}
+
+	blocked, err := c.store.GetSessionBlocked(ctx, sessionID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return false, err
+		}
+		// Tokens for unknown sessions are never accepted
+		blocked = true
+	}
+
+	c.set(sessionID, blocked)
+	return blocked, nil
+}
+
+func (c *sessionRevocationCache) Revoke(sessionID uuid.UUID) {
+	c.set(sessionID, true)
+}
+
+func (c *sessionRevocationCache) get(sessionID uuid.UUID) (revoked bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[sessionID]
+	if !ok {
+		return false, false
+	}
+	entry := elem.Value.(*revocationEntry)
+	if !entry.revoked && time.Now().After(entry.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, sessionID)
+		return false, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.revoked, true
+}
+
+func (c *sessionRevocationCache) set(sessionID uuid.UUID, revoked bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[sessionID]; ok {
+		entry := elem.Value.(*revocationEntry)
+		// A revoked session stays revoked even if a stale read says otherwise
+		entry.revoked = entry.revoked || revoked
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[sessionID] = c.lru.PushFront(&revocationEntry{
+		sessionID: sessionID,
+		revoked:   revoked,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*revocationEntry).sessionID)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	mockdb "bank/db/mock"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSessionRevocationCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	active := uuid.New()
+	unknown := uuid.New()
+
+	// Each session is read from the DB once while cached
+	store.EXPECT().GetSessionBlocked(gomock.Any(), gomock.Eq(active)).Times(1).Return(false, nil)
+	store.EXPECT().GetSessionBlocked(gomock.Any(), gomock.Eq(unknown)).Times(1).Return(false, sql.ErrNoRows)
+
+	cache := newSessionRevocationCache(store, 10, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		revoked, err := cache.IsRevoked(context.Background(), active)
+		require.NoError(t, err)
+		require.False(t, revoked)
+
+		revoked, err = cache.IsRevoked(context.Background(), unknown)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	}
+
+	cache.Revoke(active)
+	revoked, err := cache.IsRevoked(context.Background(), active)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestSessionRevocationCacheExpiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	sessionID := uuid.New()
+	gomock.InOrder(
+		store.EXPECT().GetSessionBlocked(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(false, nil),
+		store.EXPECT().GetSessionBlocked(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(true, nil),
+	)
+
+	cache := newSessionRevocationCache(store, 10, time.Millisecond)
+
+	revoked, err := cache.IsRevoked(context.Background(), sessionID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// A session blocked elsewhere is picked up once the entry expires
+	time.Sleep(5 * time.Millisecond)
+	revoked, err = cache.IsRevoked(context.Background(), sessionID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestSessionRevocationCacheEviction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetSessionBlocked(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+
+	size := 5
+	cache := newSessionRevocationCache(store, size, time.Minute)
+
+	for i := 0; i < size*2; i++ {
+		_, err := cache.IsRevoked(context.Background(), uuid.New())
+		require.NoError(t, err)
+	}
+	require.Len(t, cache.entries, size)
+	require.Equal(t, size, cache.lru.Len())
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"bank/scheduler"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1,nefield=FromAccountID"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	// Cron expression such as "0 9 1 * *", a descriptor such as "@monthly" or "@every 24h"
+	Schedule string `json:"schedule" binding:"required"`
+	// Optional, the first run is the first scheduled time at or after start_at
+	StartAt *time.Time `json:"start_at"`
+}
+
+// Schedules a recurring transfer between accounts of the same currency. Runs are
+// executed by the scheduler worker and checked again against the accounts then
+func (server *Server) createScheduledTransfer(ctx *gin.Context) (err error) {
+	var req createScheduledTransferRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	start := time.Now()
+	if req.StartAt != nil && req.StartAt.After(start) {
+		start = *req.StartAt
+	}
+	nextRunAt, err := firstScheduledRun(req.Schedule, start)
+	if err != nil {
+		return err
+	}
+
+	validFromCh := make(chan validAccountResult)
+	validToCh := make(chan validAccountResult)
+	go server.validAccount(ctx, req.FromAccountID, req.Currency, validFromCh)
+	go server.validAccount(ctx, req.ToAccountID, req.Currency, validToCh)
+
+	validFrom, validTo := <-validFromCh, <-validToCh
+	if validFrom.err != nil {
+		return validFrom.err
+	}
+	if validTo.err != nil {
+		return validTo.err
+	}
+
+	payload := authPayload(ctx)
+	if err = authorizeAccountOwner(payload, validFrom.account); err != nil {
+		return err
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:         payload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Schedule:      req.Schedule,
+		NextRunAt:     nextRunAt,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+	return
+}
+
+// Parses the schedule and returns its first run at or after start
+func firstScheduledRun(spec string, start time.Time) (time.Time, error) {
+	schedule, err := scheduler.ParseSchedule(spec)
+	if err != nil {
+		return time.Time{}, &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	next := scheduler.FirstRun(schedule, start)
+	if next.IsZero() {
+		return time.Time{}, &ApiError{Status: http.StatusBadRequest, Err: "schedule never runs"}
+	}
+	return next, nil
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// Lists the scheduled transfers of the caller
+func (server *Server) listScheduledTransfers(ctx *gin.Context) (err error) {
+	var req listScheduledTransfersRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	scheduled, err := server.store.ListScheduledTransfersByOwner(ctx, db.ListScheduledTransfersByOwnerParams{
+		Owner:  authPayload(ctx).Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+	return
+}
+
+type scheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// Loads a scheduled transfer of the caller, staff may also read those of other users
+func (server *Server) scheduledTransfer(ctx *gin.Context, write bool) (db.ScheduledTransfer, error) {
+	var uri scheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		return db.ScheduledTransfer{}, &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	scheduled, err := server.store.GetScheduledTransfer(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.ScheduledTransfer{}, &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return db.ScheduledTransfer{}, &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	payload := authPayload(ctx)
+	if scheduled.Owner != payload.Username && (write || !isStaff(payload)) {
+		return db.ScheduledTransfer{}, &ApiError{Status: http.StatusForbidden, Err: "scheduled transfer does not belong to the authenticated user"}
+	}
+	return scheduled, nil
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) (err error) {
+	scheduled, err := server.scheduledTransfer(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+	return
+}
+
+type updateScheduledTransferRequest struct {
+	Amount   *int64  `json:"amount" binding:"omitempty,gt=0"`
+	Schedule *string `json:"schedule"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+// Changes the amount or schedule of a scheduled transfer, or pauses and resumes it.
+// A new schedule or resuming a disabled transfer moves the next run to the first
+// scheduled time from now
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) (err error) {
+	scheduled, err := server.scheduledTransfer(ctx, true)
+	if err != nil {
+		return err
+	}
+	var req updateScheduledTransferRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		ID:        scheduled.ID,
+		Amount:    scheduled.Amount,
+		Schedule:  scheduled.Schedule,
+		NextRunAt: scheduled.NextRunAt,
+		Enabled:   scheduled.Enabled,
+	}
+	if req.Amount != nil {
+		arg.Amount = *req.Amount
+	}
+	if req.Enabled != nil {
+		arg.Enabled = *req.Enabled
+	}
+	resumed := arg.Enabled && !scheduled.Enabled
+	if req.Schedule != nil {
+		arg.Schedule = *req.Schedule
+	}
+	if req.Schedule != nil || resumed {
+		if arg.NextRunAt, err = firstScheduledRun(arg.Schedule, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	scheduled, err = server.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+	return
+}
+
+func (server *Server) deleteScheduledTransfer(ctx *gin.Context) (err error) {
+	scheduled, err := server.scheduledTransfer(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	if err = server.store.DeleteScheduledTransfer(ctx, scheduled.ID); err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return
+}
+
+// Lists the runs of a scheduled transfer, newest first
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) (err error) {
+	scheduled, err := server.scheduledTransfer(ctx, false)
+	if err != nil {
+		return err
+	}
+	var req listScheduledTransfersRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	runs, err := server.store.ListScheduledTransferRuns(ctx, db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               req.PageSize,
+		Offset:              (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+	return
+}
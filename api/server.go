@@ -2,10 +2,13 @@ package api
 
 import (
 	db "bank/db/sqlc"
+	"bank/fx"
+	"bank/reconciliation"
 	"bank/token"
 	"bank/util"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -16,18 +19,28 @@ import (
 type Server struct {
 	store      db.Store
 	tokenMaker token.Maker
+	revocation revocationChecker
+	rates      fx.RateProvider // nil unless cross-currency transfers are enabled
+	reconciler *reconciliation.Job
 	config     util.Config
 	router     *gin.Engine
 }
 
 func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewJWTMaker(config.TokenKey)
+	tokenMaker, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
+	rates, err := newRateProvider(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create rate provider: %w", err)
+	}
 	server := &Server{
 		store:      store,
 		tokenMaker: tokenMaker,
+		revocation: newSessionRevocationCache(store, config.RevocationCacheSize, config.RevocationCacheTTL),
+		rates:      rates,
+		reconciler: reconciliation.NewJob(store, config.ReconcileInterval),
 		config:     config,
 	}
 
@@ -37,11 +50,51 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 	// Custom validator engine
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("role", validRole)
 	}
 	
 	return server, nil
 }
 
+// Builds the key ring from TOKEN_KEY, or TOKEN_PRIVATE_KEY for asymmetric token types,
+// kept under the empty key ID for tokens issued without a kid, and the "kid:key" entries
+// of TOKEN_KEYS, then creates the TOKEN_TYPE maker
+func newTokenMaker(config util.Config) (token.Maker, error) {
+	keys := make(map[string]string)
+	unnamedKey := config.TokenKey
+	if token.IsAsymmetric(config.TokenType) {
+		unnamedKey = config.TokenPrivateKey
+	}
+	if unnamedKey != "" {
+		keys[""] = unnamedKey
+	}
+	for _, entry := range config.TokenKeys {
+		keyID, key, ok := strings.Cut(entry, ":")
+		if !ok || keyID == "" {
+			return nil, fmt.Errorf("invalid TOKEN_KEYS entry, expected kid:key")
+		}
+		keys[keyID] = key
+	}
+
+	keyRing, err := token.NewKeyRing(config.TokenPrimaryKeyID, keys)
+	if err != nil {
+		return nil, err
+	}
+	return token.NewMaker(config.TokenType, keyRing)
+}
+
+// Creates the rate provider used for cross-currency transfers when FX_ENABLED is set,
+// reading FX_RATES_FILE if configured and the FROM/TO:rate entries of FX_RATES otherwise
+func newRateProvider(config util.Config) (fx.RateProvider, error) {
+	if !config.FXEnabled {
+		return nil, nil
+	}
+	if config.FXRatesFile != "" {
+		return fx.NewFileProvider(config.FXRatesFile)
+	}
+	return fx.NewStaticProviderFromEntries(config.FXRates)
+}
+
 func (server *Server) setupRouter() {
 	router := gin.Default()
 	
@@ -49,18 +102,86 @@ func (server *Server) setupRouter() {
 	// Public routes
     router.POST("/users", makeGinHandlerFunc(server.createUser))
     router.POST("/login", makeGinHandlerFunc(server.loginUser))
+    router.POST("/tokens/renew_access", makeGinHandlerFunc(server.renewAccessToken))
+    router.GET("/.well-known/jwks.json", makeGinHandlerFunc(server.getJWKS))
 
     // Protected routes
     protected := router.Group("/")
-    protected.Use(authMiddleware(server.tokenMaker))
+    protected.Use(authMiddleware(server.tokenMaker, server.revocation))
     {
+        // Sessions
+        protected.POST("/logout", makeGinHandlerFunc(server.logoutUser))
+        protected.POST("/sessions/revoke_all", makeGinHandlerFunc(server.revokeAllSessions))
+
         // Accounts
         protected.POST("/accounts", makeGinHandlerFunc(server.createAccount))
         protected.GET("/accounts/:id", makeGinHandlerFunc(server.getAccount))
         protected.GET("/accounts", makeGinHandlerFunc(server.listAccounts))
+        protected.GET("/accounts/:id/transfers", makeGinHandlerFunc(server.listAccountTransfers))
+        protected.GET("/accounts/:id/entries", makeGinHandlerFunc(server.listAccountEntries))
+        protected.GET("/accounts/:id/statement", makeGinHandlerFunc(server.exportStatement))
+        protected.GET("/accounts/:id/limits", makeGinHandlerFunc(server.listAccountLimits))
+        protected.GET("/accounts/:id/cosigners", makeGinHandlerFunc(server.listAccountCosigners))
 
         // Transfers
         protected.POST("/transfer", makeGinHandlerFunc(server.createTransfer))
+        protected.GET("/transfers/:id", makeGinHandlerFunc(server.getTransfer))
+        protected.POST("/transfers/holds", makeGinHandlerFunc(server.createHold))
+        protected.POST("/transfers/batch", makeGinHandlerFunc(server.createBatchTransfer))
+        protected.GET("/transfers/batch/:id", makeGinHandlerFunc(server.getBatchTransfer))
+        protected.POST("/transfers/:id/capture", makeGinHandlerFunc(server.captureHold))
+        protected.POST("/transfers/:id/void", makeGinHandlerFunc(server.voidHold))
+        protected.POST("/transfers/:id/reverse", makeGinHandlerFunc(server.reverseTransfer))
+
+        // Transfers awaiting the approval of a co-signer
+        protected.GET("/transfer-requests", makeGinHandlerFunc(server.listTransferRequests))
+        protected.GET("/transfer-requests/:id", makeGinHandlerFunc(server.getTransferRequestByID))
+        protected.POST("/transfer-requests/:id/approve", makeGinHandlerFunc(server.approveTransferRequest))
+        protected.POST("/transfer-requests/:id/reject", makeGinHandlerFunc(server.rejectTransferRequest))
+
+        // Multi-leg transactions
+        protected.POST("/transactions", makeGinHandlerFunc(server.createLedgerTransaction))
+        protected.GET("/transactions/:id", makeGinHandlerFunc(server.getLedgerTransaction))
+
+        // Scheduled transfers
+        protected.POST("/scheduled-transfers", makeGinHandlerFunc(server.createScheduledTransfer))
+        protected.GET("/scheduled-transfers", makeGinHandlerFunc(server.listScheduledTransfers))
+        protected.GET("/scheduled-transfers/:id", makeGinHandlerFunc(server.getScheduledTransfer))
+        protected.PUT("/scheduled-transfers/:id", makeGinHandlerFunc(server.updateScheduledTransfer))
+        protected.DELETE("/scheduled-transfers/:id", makeGinHandlerFunc(server.deleteScheduledTransfer))
+        protected.GET("/scheduled-transfers/:id/runs", makeGinHandlerFunc(server.listScheduledTransferRuns))
+
+        // Currency conversion
+        protected.POST("/fx/quotes", makeGinHandlerFunc(server.createFxQuote))
+    }
+
+    // Staff routes for bankers and admins, bankers act as tellers for deposits and withdrawals
+    staff := protected.Group("/admin")
+    staff.Use(requireRole(util.RoleBanker, util.RoleAdmin))
+    {
+        staff.GET("/accounts", makeGinHandlerFunc(server.listAllAccounts))
+        staff.GET("/users/:username/transfers", makeGinHandlerFunc(server.listUserTransfers))
+        staff.POST("/accounts/:id/deposit", makeGinHandlerFunc(server.depositFunds))
+        staff.POST("/accounts/:id/withdraw", makeGinHandlerFunc(server.withdrawFunds))
+    }
+
+    // Admin only routes
+    admin := protected.Group("/admin")
+    admin.Use(requireRole(util.RoleAdmin))
+    {
+        admin.PUT("/accounts/:id/overdraft_limit", makeGinHandlerFunc(server.setOverdraftLimit))
+        admin.POST("/accounts/:id/freeze", makeGinHandlerFunc(server.freezeAccount))
+        admin.POST("/accounts/:id/unfreeze", makeGinHandlerFunc(server.unfreezeAccount))
+        admin.PUT("/users/:username/role", makeGinHandlerFunc(server.setUserRole))
+        admin.PUT("/accounts/:id/limits/:kind", makeGinHandlerFunc(server.setAccountLimit))
+        admin.PUT("/users/:username/limits/:kind", makeGinHandlerFunc(server.setUserLimit))
+        admin.DELETE("/limits/:id", makeGinHandlerFunc(server.deleteLimit))
+        admin.PUT("/accounts/:id/approval_threshold", makeGinHandlerFunc(server.setApprovalThreshold))
+        admin.PUT("/accounts/:id/cosigners/:username", makeGinHandlerFunc(server.addAccountCosigner))
+        admin.DELETE("/accounts/:id/cosigners/:username", makeGinHandlerFunc(server.deleteAccountCosigner))
+        admin.GET("/reconciliation", makeGinHandlerFunc(server.getReconciliationReport))
+        admin.POST("/reconciliation", makeGinHandlerFunc(server.runReconciliation))
+        admin.GET("/stats/transactions", makeGinHandlerFunc(server.getTxStats))
     }
 	
 	server.router = router
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bank/token"
+	"bank/util"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTokenMaker(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config util.Config
+		check  func(t *testing.T, maker token.Maker, err error)
+	}{
+		{
+			name:   "DefaultJWT",
+			config: util.Config{TokenKey: util.RandomString(32)},
+			check: func(t *testing.T, maker token.Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &token.JWTMaker{}, maker)
+			},
+		},
+		{
+			name: "PasetoKeyRing",
+			config: util.Config{
+				TokenType:         token.TypePaseto,
+				TokenKeys:         []string{"k1:" + util.RandomString(32), "k2:" + util.RandomString(32)},
+				TokenPrimaryKeyID: "k2",
+			},
+			check: func(t *testing.T, maker token.Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &token.PasetoMaker{}, maker)
+			},
+		},
+		{
+			name: "InvalidKeyEntry",
+			config: util.Config{
+				TokenKey:  util.RandomString(32),
+				TokenKeys: []string{util.RandomString(32)},
+			},
+			check: func(t *testing.T, maker token.Maker, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "UnknownPrimaryKey",
+			config: util.Config{
+				TokenKeys:         []string{"k1:" + util.RandomString(32)},
+				TokenPrimaryKeyID: "k2",
+			},
+			check: func(t *testing.T, maker token.Maker, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := newTokenMaker(tc.config)
+			tc.check(t, maker, err)
+		})
+	}
+}
+
+func TestNewRateProvider(t *testing.T) {
+	rates, err := newRateProvider(util.Config{FXRates: []string{"USD/EUR:0.9"}})
+	require.NoError(t, err)
+	require.Nil(t, rates)
+
+	rates, err = newRateProvider(util.Config{FXEnabled: true, FXRates: []string{"USD/EUR:0.9"}})
+	require.NoError(t, err)
+	rate, err := rates.Rate(context.Background(), util.USD, util.EUR)
+	require.NoError(t, err)
+	require.Equal(t, "0.90000000", rate.String())
+
+	_, err = newRateProvider(util.Config{FXEnabled: true, FXRates: []string{"USD/EUR"}})
+	require.Error(t, err)
+}
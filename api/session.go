@@ -0,0 +1,44 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Blocks the session of the token used for the request
+func (server *Server) logoutUser(ctx *gin.Context) (err error) {
+	payload := authPayload(ctx)
+
+	rows, err := server.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       payload.SessionID,
+		Username: payload.Username,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	if rows == 0 {
+		return &ApiError{Status: http.StatusNotFound, Err: "session not found"}
+	}
+	server.revocation.Revoke(payload.SessionID)
+
+	ctx.JSON(http.StatusOK, gin.H{"session_id": payload.SessionID})
+	return
+}
+
+// Blocks every active session of the authenticated user, including the current one
+func (server *Server) revokeAllSessions(ctx *gin.Context) (err error) {
+	payload := authPayload(ctx)
+
+	sessionIDs, err := server.store.BlockUserSessions(ctx, payload.Username)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	for _, sessionID := range sessionIDs {
+		server.revocation.Revoke(sessionID)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"revoked_sessions": len(sessionIDs)})
+	return
+}
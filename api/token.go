@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bank/token"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+// Issues a new access token for a refresh token backed by a valid session
+func (server *Server) renewAccessToken(ctx *gin.Context) (err error) {
+	var req renewAccessTokenRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		return &ApiError{Status: http.StatusUnauthorized, Err: err.Error()}
+	}
+	if refreshPayload.Type != token.RefreshToken {
+		return &ApiError{Status: http.StatusUnauthorized, Err: "not a refresh token"}
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.SessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusUnauthorized, Err: "session not found"}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	if session.IsBlocked {
+		return &ApiError{Status: http.StatusUnauthorized, Err: "session is blocked"}
+	}
+	if session.Username != refreshPayload.Username {
+		return &ApiError{Status: http.StatusUnauthorized, Err: "session does not belong to the user"}
+	}
+	if session.RefreshToken != req.RefreshToken {
+		return &ApiError{Status: http.StatusUnauthorized, Err: "mismatched session token"}
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return &ApiError{Status: http.StatusUnauthorized, Err: "session has expired"}
+	}
+
+	// The role is read again so role changes apply to the next access token
+	user, err := server.store.GetUser(ctx, session.Username)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, session.ID, server.config.TokenDuration)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: "failed to generate token"}
+	}
+
+	rsp := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpireAt,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+	return
+}
+
+type jwksResponse struct {
+	Keys []token.JWK `json:"keys"`
+}
+
+// Publishes the public keys of asymmetric token makers so other services can verify tokens
+func (server *Server) getJWKS(ctx *gin.Context) (err error) {
+	provider, ok := server.tokenMaker.(token.PublicKeyProvider)
+	if !ok {
+		return &ApiError{Status: http.StatusNotFound, Err: "tokens are not signed with public keys"}
+	}
+
+	ctx.JSON(http.StatusOK, jwksResponse{Keys: provider.PublicKeys()})
+	return
+}
@@ -3,10 +3,14 @@ package api
 import (
 	db "bank/db/sqlc"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type createTransferRequest struct {
@@ -14,6 +18,10 @@ type createTransferRequest struct {
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
 	Amount        int64  `json:"amount" binding:"required,gt=0"`
 	Currency      string `json:"currency" binding:"required,currency"`
+	// Optional, converts at the rate locked by POST /fx/quotes
+	QuoteID string `json:"quote_id" binding:"omitempty,uuid"`
+	// Optional memo shown on the statements of both accounts
+	Description string `json:"description" binding:"max=255"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) (err error) {
@@ -22,30 +30,87 @@ func (server *Server) createTransfer(ctx *gin.Context) (err error) {
 		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
 	}
 
-	validFromCh := make(chan error)
-	validToCh := make(chan error)
+	idempotency, err := idempotencyParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	stored, err := server.storedIdempotentResponse(ctx, idempotency)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		ctx.Data(int(stored.Status), gin.MIMEJSON+"; charset=utf-8", stored.Response)
+		return
+	}
+
+	validFromCh := make(chan validAccountResult)
+	validToCh := make(chan validAccountResult)
 
+	// The destination may hold another currency when conversion is enabled or quoted
+	toCurrency := req.Currency
+	if server.rates != nil || req.QuoteID != "" {
+		toCurrency = ""
+	}
 	go server.validAccount(ctx, req.FromAccountID, req.Currency, validFromCh)
-	go server.validAccount(ctx, req.ToAccountID, req.Currency, validToCh)
+	go server.validAccount(ctx, req.ToAccountID, toCurrency, validToCh)
 
 	validFrom, validTo := <-validFromCh, <-validToCh
 
-	if validFrom != nil {
-		return validFrom
+	if validFrom.err != nil {
+		return validFrom.err
+	}
+
+	if validTo.err != nil {
+		return validTo.err
 	}
 
-	if validTo != nil {
-		return validTo
+	// Money can only be debited from an account owned by the caller
+	if err = authorizeAccountOwner(authPayload(ctx), validFrom.account); err != nil {
+		return err
+	}
+
+	// Transfers above the approval threshold of the source account wait for a co-signer,
+	// the store checks again when the transfer is posted
+	if threshold := validFrom.account.ApprovalThreshold; threshold.Valid && req.Amount > threshold.Int64 {
+		return server.requestTransferApproval(ctx, req, idempotency)
 	}
 
 	arg := db.TransferTxParms{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		Description:   req.Description,
+		Idempotency:   idempotency,
+	}
+	if req.QuoteID != "" {
+		quoteID := uuid.MustParse(req.QuoteID)
+		if err = server.validQuote(ctx, quoteID, validFrom.account, validTo.account, req.Amount); err != nil {
+			return err
+		}
+		arg.QuoteID = uuid.NullUUID{UUID: quoteID, Valid: true}
+	} else if validTo.account.Currency != validFrom.account.Currency {
+		if err = server.convertTransfer(ctx, &arg, validFrom.account.Currency, validTo.account.Currency); err != nil {
+			return err
+		}
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
+		// The threshold was lowered after the account was validated
+		if errors.Is(err, db.ErrApprovalRequired) {
+			return server.requestTransferApproval(ctx, req, idempotency)
+		}
+		var limitErr *db.LimitExceededError
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrQuoteUnavailable) || errors.As(err, &limitErr) {
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == idempotencyKeyConstraint {
+			return &ApiError{Status: http.StatusConflict, Err: "a request with this Idempotency-Key is already being processed"}
+		}
+		// One of the accounts was deleted after it was validated
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: "account not found"}
+		}
 		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
 	}
 
@@ -53,19 +118,169 @@ func (server *Server) createTransfer(ctx *gin.Context) (err error) {
 	return
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string, valid chan<- error) {
+// Sets the destination amount and applied rate of a cross-currency transfer
+func (server *Server) convertTransfer(ctx *gin.Context, arg *db.TransferTxParms, fromCurrency string, toCurrency string) error {
+	rate, toAmount, err := server.convertAmount(ctx, arg.Amount, fromCurrency, toCurrency)
+	if err != nil {
+		return err
+	}
+	arg.ToAmount = toAmount
+	arg.ExchangeRate = rate.String()
+	return nil
+}
+
+type validAccountResult struct {
+	account db.Account
+	err     error
+}
+
+// Loads a transfer account and checks it is usable, an empty currency accepts any currency
+func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string, valid chan<- validAccountResult) {
 	account, err := server.store.GetAccount(ctx, accountId)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			valid <- &ApiError{Status: http.StatusNotFound, Err: fmt.Sprintf("Account ID: %d not found", accountId)}
+			valid <- validAccountResult{err: &ApiError{Status: http.StatusNotFound, Err: fmt.Sprintf("Account ID: %d not found", accountId)}}
 			return
 		}
-		valid <- &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+		valid <- validAccountResult{err: &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}}
+		return
+	}
+	if account.IsFrozen {
+		valid <- validAccountResult{err: &ApiError{Status: http.StatusForbidden, Err: fmt.Sprintf("Account ID: %d is frozen", accountId)}}
+		return
+	}
+	if account.IsClearing {
+		valid <- validAccountResult{err: &ApiError{Status: http.StatusForbidden, Err: fmt.Sprintf("Account ID: %d is a clearing account", accountId)}}
 		return
 	}
-	if account.Currency != currency {
-		valid <- &ApiError{Status: http.StatusBadRequest, Err: fmt.Sprintf("Currency %s not supported on account ID %d", currency, accountId)}
+	if currency != "" && account.Currency != currency {
+		valid <- validAccountResult{err: &ApiError{Status: http.StatusBadRequest, Err: fmt.Sprintf("Currency %s not supported on account ID %d", currency, accountId)}}
 		return
 	}
-	valid <- err
+	valid <- validAccountResult{account: account}
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// Returns a transfer sent or received by one of the caller's accounts
+func (server *Server) getTransfer(ctx *gin.Context) (err error) {
+	var req getTransferRequest
+	if err = ctx.ShouldBindUri(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	if err = server.authorizeTransferAccess(ctx, transfer); err != nil {
+		return err
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+	return
+}
+
+// Checks that the caller is staff or owns either side of the transfer
+func (server *Server) authorizeTransferAccess(ctx *gin.Context, transfer db.Transfer) error {
+	payload := authPayload(ctx)
+	if isStaff(payload) {
+		return nil
+	}
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+		}
+		if account.Owner == payload.Username {
+			return nil
+		}
+	}
+	return &ApiError{Status: http.StatusForbidden, Err: "transfer does not belong to the authenticated user"}
+}
+
+type listAccountTransfersRequest struct {
+	Direction      string     `form:"direction" binding:"omitempty,oneof=in out both"`
+	CounterpartyID *int64     `form:"counterparty_id" binding:"omitempty,min=1"`
+	StartTime      *time.Time `form:"start_time"`
+	EndTime        *time.Time `form:"end_time"`
+	MinAmount      *int64     `form:"min_amount" binding:"omitempty,min=1"`
+	MaxAmount      *int64     `form:"max_amount" binding:"omitempty,min=1"`
+	// Defaults to posted transfers, all includes pending, voided and expired holds
+	Status   string `form:"status" binding:"omitempty,oneof=pending posted voided expired all"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+type listAccountTransfersResponse struct {
+	Transfers  []db.Transfer `json:"transfers"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// Lists the transfers of an account newest first. The next_cursor of a page is passed
+// back as cursor to read the following page
+func (server *Server) listAccountTransfers(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req listAccountTransfersRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	if req.StartTime != nil && req.EndTime != nil && !req.StartTime.Before(*req.EndTime) {
+		return &ApiError{Status: http.StatusBadRequest, Err: "start_time must be before end_time"}
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		return &ApiError{Status: http.StatusBadRequest, Err: "min_amount must not exceed max_amount"}
+	}
+	beforeID, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return err
+	}
+
+	account, err := server.readableAccount(ctx, uri.ID)
+	if err != nil {
+		return err
+	}
+
+	direction := req.Direction
+	if direction == "" {
+		direction = "both"
+	}
+	status := sql.NullString{String: db.TransferPosted, Valid: true}
+	if req.Status == "all" {
+		status = sql.NullString{}
+	} else if req.Status != "" {
+		status.String = req.Status
+	}
+	arg := db.ListAccountTransfersParams{
+		Direction:      direction,
+		AccountID:      account.ID,
+		CounterpartyID: nullInt64(req.CounterpartyID),
+		CreatedFrom:    nullTime(req.StartTime),
+		CreatedTo:      nullTime(req.EndTime),
+		MinAmount:      nullInt64(req.MinAmount),
+		MaxAmount:      nullInt64(req.MaxAmount),
+		Status:         status,
+		BeforeID:       beforeID,
+		Limit:          req.PageSize,
+	}
+	transfers, err := server.store.ListAccountTransfers(ctx, arg)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	rsp := listAccountTransfersResponse{Transfers: transfers}
+	if len(transfers) > 0 {
+		rsp.NextCursor = nextCursor(len(transfers), req.PageSize, transfers[len(transfers)-1].ID)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+	return
 }
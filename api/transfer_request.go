@@ -0,0 +1,339 @@
+package api
+
+import (
+	db "bank/db/sqlc"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Validity of a transfer request when TRANSFER_REQUEST_DURATION is not set
+const defaultTransferRequestDuration = 72 * time.Hour
+
+// Records a transfer above the approval threshold of its source account as a request
+// that is posted once a co-signer approves it
+func (server *Server) requestTransferApproval(ctx *gin.Context, req createTransferRequest, idempotency *db.IdempotencyParams) error {
+	// A quote expires long before most requests are decided, the rate of the approval applies
+	if req.QuoteID != "" {
+		return &ApiError{Status: http.StatusBadRequest, Err: "transfers awaiting approval cannot use an fx quote"}
+	}
+
+	duration := server.config.TransferRequestDuration
+	if duration <= 0 {
+		duration = defaultTransferRequestDuration
+	}
+	// The transfer is accepted but not posted, retries replay the same status
+	if idempotency != nil {
+		idempotency.Status = http.StatusAccepted
+	}
+	request, err := server.store.CreateTransferRequestTx(ctx, db.CreateTransferRequestTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		RequestedBy:   authPayload(ctx).Username,
+		ExpiresAt:     time.Now().Add(duration),
+		Idempotency:   idempotency,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == idempotencyKeyConstraint {
+			return &ApiError{Status: http.StatusConflict, Err: "a request with this Idempotency-Key is already being processed"}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusAccepted, request)
+	return nil
+}
+
+type listTransferRequestsRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending approved rejected expired"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// Lists the requests made by the caller and those the caller may approve as co-signer,
+// newest first
+func (server *Server) listTransferRequests(ctx *gin.Context) (err error) {
+	var req listTransferRequestsRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	requests, err := server.store.ListTransferRequests(ctx, db.ListTransferRequestsParams{
+		Username: authPayload(ctx).Username,
+		Status:   sql.NullString{String: req.Status, Valid: req.Status != ""},
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, requests)
+	return
+}
+
+type getTransferRequestResponse struct {
+	Request db.TransferRequest `json:"request"`
+	// Audit trail, oldest first
+	Events []db.TransferRequestEvent `json:"events"`
+}
+
+// Returns a request with its audit trail to the requester, the co-signers of its
+// source account or bank staff
+func (server *Server) getTransferRequestByID(ctx *gin.Context) (err error) {
+	request, err := server.loadTransferRequest(ctx)
+	if err != nil {
+		return err
+	}
+	payload := authPayload(ctx)
+	if request.RequestedBy != payload.Username && !isStaff(payload) {
+		if err = server.authorizeCosigner(ctx, request); err != nil {
+			return err
+		}
+	}
+
+	events, err := server.store.ListTransferRequestEvents(ctx, request.ID)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, getTransferRequestResponse{Request: request, Events: events})
+	return
+}
+
+// Posts the transfer of a pending request. Only a co-signer of the source account other
+// than the requester may approve, and the transfer must still pass the checks of
+// POST /transfer at that time
+func (server *Server) approveTransferRequest(ctx *gin.Context) (err error) {
+	request, err := server.loadTransferRequest(ctx)
+	if err != nil {
+		return err
+	}
+	if err = server.authorizeCosigner(ctx, request); err != nil {
+		return err
+	}
+	payload := authPayload(ctx)
+	if request.RequestedBy == payload.Username {
+		return &ApiError{Status: http.StatusForbidden, Err: db.ErrSelfApproval.Error()}
+	}
+
+	// The accounts may have been frozen since the request was made
+	validFromCh := make(chan validAccountResult)
+	validToCh := make(chan validAccountResult)
+	go server.validAccount(ctx, request.FromAccountID, "", validFromCh)
+	go server.validAccount(ctx, request.ToAccountID, "", validToCh)
+	validFrom, validTo := <-validFromCh, <-validToCh
+	if validFrom.err != nil {
+		return validFrom.err
+	}
+	if validTo.err != nil {
+		return validTo.err
+	}
+
+	arg := db.ApproveTransferRequestTxParams{
+		RequestID: request.ID,
+		Approver:  payload.Username,
+		Now:       time.Now(),
+	}
+	if validTo.account.Currency != validFrom.account.Currency {
+		if server.rates == nil {
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: "currency conversion is disabled"}
+		}
+		transfer := db.TransferTxParms{Amount: request.Amount}
+		if err = server.convertTransfer(ctx, &transfer, validFrom.account.Currency, validTo.account.Currency); err != nil {
+			return err
+		}
+		arg.ToAmount = transfer.ToAmount
+		arg.ExchangeRate = transfer.ExchangeRate
+	}
+
+	result, err := server.store.ApproveTransferRequestTx(ctx, arg)
+	if err != nil {
+		var limitErr *db.LimitExceededError
+		switch {
+		case errors.Is(err, db.ErrTransferRequestNotPending), errors.Is(err, db.ErrTransferRequestExpired):
+			return &ApiError{Status: http.StatusConflict, Err: err.Error()}
+		case errors.Is(err, db.ErrSelfApproval):
+			return &ApiError{Status: http.StatusForbidden, Err: err.Error()}
+		case errors.Is(err, db.ErrInsufficientFunds), errors.As(err, &limitErr):
+			return &ApiError{Status: http.StatusUnprocessableEntity, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+	return
+}
+
+type rejectTransferRequestRequest struct {
+	// Optional, kept in the audit trail
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// Declines a pending request on behalf of a co-signer of its source account
+func (server *Server) rejectTransferRequest(ctx *gin.Context) (err error) {
+	var req rejectTransferRequestRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	request, err := server.loadTransferRequest(ctx)
+	if err != nil {
+		return err
+	}
+	if err = server.authorizeCosigner(ctx, request); err != nil {
+		return err
+	}
+
+	request, err = server.store.RejectTransferRequestTx(ctx, db.RejectTransferRequestTxParams{
+		RequestID: request.ID,
+		Rejecter:  authPayload(ctx).Username,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrTransferRequestNotPending) {
+			return &ApiError{Status: http.StatusConflict, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, request)
+	return
+}
+
+// Loads the transfer request named by the :id parameter
+func (server *Server) loadTransferRequest(ctx *gin.Context) (db.TransferRequest, error) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		return db.TransferRequest{}, &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	request, err := server.store.GetTransferRequest(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.TransferRequest{}, &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return db.TransferRequest{}, &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	return request, nil
+}
+
+// Checks that the caller co-signs the source account of the request
+func (server *Server) authorizeCosigner(ctx *gin.Context, request db.TransferRequest) error {
+	cosigner, err := server.store.IsAccountCosigner(ctx, db.IsAccountCosignerParams{
+		AccountID: request.FromAccountID,
+		Username:  authPayload(ctx).Username,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+	if !cosigner {
+		return &ApiError{Status: http.StatusForbidden, Err: "the authenticated user is not a co-signer of the account"}
+	}
+	return nil
+}
+
+// Lists the users who approve the transfer requests of an account
+func (server *Server) listAccountCosigners(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	account, err := server.readableAccount(ctx, uri.ID)
+	if err != nil {
+		return err
+	}
+
+	cosigners, err := server.store.ListAccountCosigners(ctx, account.ID)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, cosigners)
+	return
+}
+
+type accountCosignerRequest struct {
+	ID       int64  `uri:"id" binding:"required,min=1"`
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// Lets a user approve the transfer requests of an account
+func (server *Server) addAccountCosigner(ctx *gin.Context) (err error) {
+	var uri accountCosignerRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	cosigner, err := server.store.AddAccountCosigner(ctx, db.AddAccountCosignerParams{
+		AccountID: uri.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return &ApiError{Status: http.StatusNotFound, Err: pqErr.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, cosigner)
+	return
+}
+
+func (server *Server) deleteAccountCosigner(ctx *gin.Context) (err error) {
+	var uri accountCosignerRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	cosigner, err := server.store.DeleteAccountCosigner(ctx, db.DeleteAccountCosignerParams{
+		AccountID: uri.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, cosigner)
+	return
+}
+
+type setApprovalThresholdRequest struct {
+	// Null removes the threshold so no transfer waits for approval
+	ApprovalThreshold *int64 `json:"approval_threshold" binding:"omitempty,min=0"`
+}
+
+// Sets the amount above which transfers from an account wait for a co-signer
+func (server *Server) setApprovalThreshold(ctx *gin.Context) (err error) {
+	var uri getAccountRequest
+	if err = ctx.ShouldBindUri(&uri); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+	var req setApprovalThresholdRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		return &ApiError{Status: http.StatusBadRequest, Err: err.Error()}
+	}
+
+	arg := db.SetApprovalThresholdParams{
+		ID:                uri.ID,
+		ApprovalThreshold: nullInt64(req.ApprovalThreshold),
+	}
+	account, err := server.store.SetApprovalThreshold(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &ApiError{Status: http.StatusNotFound, Err: err.Error()}
+		}
+		return &ApiError{Status: http.StatusInternalServerError, Err: err.Error()}
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+	return
+}
@@ -0,0 +1,852 @@
+package api
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bank/util"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateTransferApprovalAPI(t *testing.T) {
+	user1, _ := randomUser()
+	user2, _ := randomUser()
+
+	account1 := randomAccount(user1.Username)
+	account1.Currency = util.USD
+	account1.ApprovalThreshold = sql.NullInt64{Int64: 1000, Valid: true}
+	account2 := randomAccount(user2.Username)
+	account2.Currency = util.USD
+
+	idempotencyKey := util.RandomString(16)
+	reqHash, err := requestHash(http.MethodPost, "/transfer", createTransferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1001,
+		Currency:      util.USD,
+		Description:   "Supplier invoice",
+	})
+	require.NoError(t, err)
+	pending := db.TransferRequest{
+		ID:            3,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1001,
+		Description:   "Supplier invoice",
+		RequestedBy:   user1.Username,
+		Status:        db.TransferRequestPending,
+	}
+	storedResponse, err := json.Marshal(pending)
+	require.NoError(t, err)
+
+	requireBodyMatchTransferRequest := func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		var request db.TransferRequest
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &request))
+		require.Equal(t, pending.ID, request.ID)
+		require.Equal(t, account1.ID, request.FromAccountID)
+		require.Equal(t, account2.ID, request.ToAccountID)
+		require.Equal(t, int64(1001), request.Amount)
+		require.Equal(t, "Supplier invoice", request.Description)
+		require.Equal(t, user1.Username, request.RequestedBy)
+		require.Equal(t, db.TransferRequestPending, request.Status)
+	}
+
+	testCases := []struct {
+		name           string
+		body           gin.H
+		idempotencyKey string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "AboveThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        util.USD,
+				"description":     "Supplier invoice",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					CreateTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateTransferRequestTxParams) (db.TransferRequest, error) {
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, int64(1001), arg.Amount)
+						require.Equal(t, "Supplier invoice", arg.Description)
+						require.Equal(t, user1.Username, arg.RequestedBy)
+						require.WithinDuration(t, time.Now().Add(defaultTransferRequestDuration), arg.ExpiresAt, time.Second)
+						require.Nil(t, arg.Idempotency)
+						return pending, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				requireBodyMatchTransferRequest(t, recorder)
+			},
+		},
+		{
+			name: "AboveThresholdIdempotent",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        util.USD,
+				"description":     "Supplier invoice",
+			},
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					CreateTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateTransferRequestTxParams) (db.TransferRequest, error) {
+						require.Equal(t, &db.IdempotencyParams{
+							Key:         idempotencyKey,
+							Username:    user1.Username,
+							RequestHash: reqHash,
+							Status:      http.StatusAccepted,
+						}, arg.Idempotency)
+						return pending, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				requireBodyMatchTransferRequest(t, recorder)
+			},
+		},
+		{
+			name: "IdempotentRetry",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        util.USD,
+				"description":     "Supplier invoice",
+			},
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{Username: user1.Username, Key: idempotencyKey})).
+					Times(1).
+					Return(db.IdempotencyKey{
+						Key:         idempotencyKey,
+						Username:    user1.Username,
+						RequestHash: reqHash,
+						Response:    storedResponse,
+						Status:      http.StatusAccepted,
+					}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+				requireBodyMatchTransferRequest(t, recorder)
+			},
+		},
+		{
+			name: "ThresholdLoweredBeforePosting",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1000,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, fmt.Errorf("%w %d", db.ErrApprovalRequired, account1.ID))
+				store.EXPECT().
+					CreateTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferRequest{ID: 4, Status: db.TransferRequestPending, Amount: 1000}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				var request db.TransferRequest
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &request))
+				require.Equal(t, int64(4), request.ID)
+				require.Equal(t, db.TransferRequestPending, request.Status)
+			},
+		},
+		{
+			name: "AtThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1000,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CreateTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{TransferID: 9}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteAboveThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          5000,
+				"currency":        util.USD,
+				"quote_id":        "4b2c1d3e-5f60-4718-8a9b-0c1d2e3f4a5b",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			if tc.idempotencyKey != "" {
+				request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			}
+
+			addAuthorization(t, request, server.tokenMaker, user1.Username, util.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestApproveTransferRequestAPI(t *testing.T) {
+	maker, _ := randomUser()
+	checker, _ := randomUser()
+	payee, _ := randomUser()
+
+	from := randomAccount(maker.Username)
+	from.Currency = util.USD
+	to := randomAccount(payee.Username)
+	to.Currency = util.USD
+
+	request := db.TransferRequest{
+		ID:            3,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        5000,
+		RequestedBy:   maker.Username,
+		Status:        db.TransferRequestPending,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	cosigner := func(store *mockdb.MockStore, username string, ok bool) {
+		store.EXPECT().
+			IsAccountCosigner(gomock.Any(), gomock.Eq(db.IsAccountCosignerParams{AccountID: from.ID, Username: username})).
+			Times(1).
+			Return(ok, nil)
+	}
+	accounts := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to.ID)).Times(1).Return(to, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				cosigner(store, checker.Username, true)
+				accounts(store)
+				approved := request
+				approved.Status = db.TransferRequestApproved
+				approved.DecidedBy = sql.NullString{String: checker.Username, Valid: true}
+				approved.TransferID = sql.NullInt64{Int64: 11, Valid: true}
+				store.EXPECT().
+					ApproveTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.ApproveTransferRequestTxParams) (db.ApproveTransferRequestTxResult, error) {
+						require.Equal(t, request.ID, arg.RequestID)
+						require.Equal(t, checker.Username, arg.Approver)
+						require.Zero(t, arg.ToAmount)
+						require.WithinDuration(t, time.Now(), arg.Now, time.Second)
+						return db.ApproveTransferRequestTxResult{Request: approved, Transfer: db.TransferTxResult{TransferID: 11}}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var result db.ApproveTransferRequestTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+				require.Equal(t, db.TransferRequestApproved, result.Request.Status)
+				require.Equal(t, checker.Username, result.Request.DecidedBy.String)
+				require.Equal(t, int64(11), result.Transfer.TransferID)
+			},
+		},
+		{
+			name:     "NotCosigner",
+			username: payee.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				cosigner(store, payee.Username, false)
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "SelfApproval",
+			username: maker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				cosigner(store, maker.Username, true)
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "FrozenAccount",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				cosigner(store, checker.Username, true)
+				frozen := to
+				frozen.IsFrozen = true
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to.ID)).Times(1).Return(frozen, nil)
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotPending",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				cosigner(store, checker.Username, true)
+				accounts(store)
+				store.EXPECT().
+					ApproveTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrTransferRequestNotPending)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "Expired",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				cosigner(store, checker.Username, true)
+				accounts(store)
+				store.EXPECT().
+					ApproveTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrTransferRequestExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				cosigner(store, checker.Username, true)
+				accounts(store)
+				store.EXPECT().
+					ApproveTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(db.TransferRequest{}, sql.ErrNoRows)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer-requests/%d/approve", request.ID)
+			httpRequest, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, httpRequest, server.tokenMaker, tc.username, util.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, httpRequest)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRejectTransferRequestAPI(t *testing.T) {
+	maker, _ := randomUser()
+	checker, _ := randomUser()
+
+	request := db.TransferRequest{
+		ID:            3,
+		FromAccountID: 7,
+		ToAccountID:   8,
+		Amount:        5000,
+		RequestedBy:   maker.Username,
+		Status:        db.TransferRequestPending,
+	}
+
+	testCases := []struct {
+		name          string
+		body          []byte
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: []byte(`{"reason":"Duplicate invoice"}`),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				arg := db.RejectTransferRequestTxParams{
+					RequestID: request.ID,
+					Rejecter:  checker.Username,
+					Reason:    "Duplicate invoice",
+				}
+				rejected := request
+				rejected.Status = db.TransferRequestRejected
+				store.EXPECT().RejectTransferRequestTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rejected, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rejected db.TransferRequest
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rejected))
+				require.Equal(t, db.TransferRequestRejected, rejected.Status)
+			},
+		},
+		{
+			name: "NoReason",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().RejectTransferRequestTx(gomock.Any(), gomock.Any()).Times(1).Return(request, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotCosigner",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().RejectTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotPending",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().
+					RejectTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferRequest{}, db.ErrTransferRequestNotPending)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer-requests/%d/reject", request.ID)
+			httpRequest, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+			httpRequest.Header.Set("Content-Type", "application/json")
+
+			addAuthorization(t, httpRequest, server.tokenMaker, checker.Username, util.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, httpRequest)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferRequestAPI(t *testing.T) {
+	maker, _ := randomUser()
+	checker, _ := randomUser()
+	stranger, _ := randomUser()
+
+	request := db.TransferRequest{
+		ID:            3,
+		FromAccountID: 7,
+		ToAccountID:   8,
+		Amount:        5000,
+		RequestedBy:   maker.Username,
+		Status:        db.TransferRequestApproved,
+		DecidedBy:     sql.NullString{String: checker.Username, Valid: true},
+	}
+	events := []db.TransferRequestEvent{
+		{ID: 1, RequestID: request.ID, Action: db.TransferRequestRequested, Actor: sql.NullString{String: maker.Username, Valid: true}},
+		{ID: 2, RequestID: request.ID, Action: db.TransferRequestApproved, Actor: sql.NullString{String: checker.Username, Valid: true}},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Requester",
+			username: maker.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListTransferRequestEvents(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response getTransferRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, request.ID, response.Request.ID)
+				require.Len(t, response.Events, 2)
+				require.Equal(t, checker.Username, response.Events[1].Actor.String)
+			},
+		},
+		{
+			name:     "Cosigner",
+			username: checker.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().ListTransferRequestEvents(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Staff",
+			username: stranger.Username,
+			role:     util.RoleBanker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListTransferRequestEvents(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Stranger",
+			username: stranger.Username,
+			role:     util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().IsAccountCosigner(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().ListTransferRequestEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			httpRequest, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/transfer-requests/%d", request.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, httpRequest, server.tokenMaker, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, httpRequest)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListTransferRequestsAPI(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Pending",
+			query: "?status=pending&page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListTransferRequestsParams{
+					Username: user.Username,
+					Status:   sql.NullString{String: db.TransferRequestPending, Valid: true},
+					Limit:    5,
+					Offset:   5,
+				}
+				store.EXPECT().
+					ListTransferRequests(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.TransferRequest{{ID: 3, Status: db.TransferRequestPending}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var requests []db.TransferRequest
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &requests))
+				require.Len(t, requests, 1)
+			},
+		},
+		{
+			name:  "AnyStatus",
+			query: "?page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListTransferRequestsParams{Username: user.Username, Limit: 5}
+				store.EXPECT().ListTransferRequests(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.TransferRequest{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidStatus",
+			query: "?status=cancelled&page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListTransferRequests(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			httpRequest, err := http.NewRequest(http.MethodGet, "/transfer-requests"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, httpRequest, server.tokenMaker, user.Username, util.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, httpRequest)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestApprovalSettingsAPI(t *testing.T) {
+	admin, _ := randomUser()
+	cosigner, _ := randomUser()
+	account := randomAccount(admin.Username)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "SetThreshold",
+			method: http.MethodPut,
+			url:    fmt.Sprintf("/admin/accounts/%d/approval_threshold", account.ID),
+			body:   `{"approval_threshold":10000}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SetApprovalThresholdParams{
+					ID:                account.ID,
+					ApprovalThreshold: sql.NullInt64{Int64: 10000, Valid: true},
+				}
+				updated := account
+				updated.ApprovalThreshold = arg.ApprovalThreshold
+				store.EXPECT().SetApprovalThreshold(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"approval_threshold":{"Int64":10000,"Valid":true}`)
+			},
+		},
+		{
+			name:   "ClearThreshold",
+			method: http.MethodPut,
+			url:    fmt.Sprintf("/admin/accounts/%d/approval_threshold", account.ID),
+			body:   `{"approval_threshold":null}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SetApprovalThresholdParams{ID: account.ID}
+				store.EXPECT().SetApprovalThreshold(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "NegativeThreshold",
+			method: http.MethodPut,
+			url:    fmt.Sprintf("/admin/accounts/%d/approval_threshold", account.ID),
+			body:   `{"approval_threshold":-1}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetApprovalThreshold(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "AddCosigner",
+			method: http.MethodPut,
+			url:    fmt.Sprintf("/admin/accounts/%d/cosigners/%s", account.ID, cosigner.Username),
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.AddAccountCosignerParams{AccountID: account.ID, Username: cosigner.Username}
+				store.EXPECT().
+					AddAccountCosigner(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.AccountCosigner{AccountID: account.ID, Username: cosigner.Username}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "AddUnknownCosigner",
+			method: http.MethodPut,
+			url:    fmt.Sprintf("/admin/accounts/%d/cosigners/%s", account.ID, cosigner.Username),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AddAccountCosigner(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountCosigner{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "DeleteMissingCosigner",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/admin/accounts/%d/cosigners/%s", account.ID, cosigner.Username),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteAccountCosigner(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountCosigner{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			httpRequest, err := http.NewRequest(tc.method, tc.url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			httpRequest.Header.Set("Content-Type", "application/json")
+
+			addAuthorization(t, httpRequest, server.tokenMaker, admin.Username, util.RoleAdmin, time.Minute)
+			server.router.ServeHTTP(recorder, httpRequest)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
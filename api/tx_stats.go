@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Returns how many transactions were committed and retried after serialization
+// failures or deadlocks since the server started, to tune TX_MAX_RETRIES
+func (server *Server) getTxStats(ctx *gin.Context) (err error) {
+	ctx.JSON(http.StatusOK, server.store.TxStats())
+	return
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bank/util"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetTxStatsAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	stats := db.TxStats{Committed: 40, Retries: 3, Exhausted: 1}
+	store.EXPECT().TxStats().Times(1).Return(stats)
+
+	server := NewTestServer(t, store)
+
+	send := func(role string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/admin/stats/transactions", nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, util.RandomOwner(), role, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusForbidden, send(util.RoleBanker).Code)
+
+	recorder := send(util.RoleAdmin)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got db.TxStats
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, stats, got)
+}
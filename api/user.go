@@ -2,12 +2,15 @@ package api
 
 import (
 	db "bank/db/sqlc"
+	"bank/token"
 	"bank/util"
 	"database/sql"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
@@ -24,6 +27,7 @@ type userResponse struct {
 	Username string `json:"username"`
 	FullName string `json:"full_name"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
 }
 
 func newUserResponse(user db.User) userResponse {
@@ -31,6 +35,7 @@ func newUserResponse(user db.User) userResponse {
 		Username: user.Username,
 		FullName: user.FullName,
 		Email:    user.Email,
+		Role:     user.Role,
 	}
 }
 func (s *Server) createUser(ctx *gin.Context) (err error) {
@@ -91,8 +96,12 @@ type loginUserRequest struct {
 }
 
 type loginUserResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
 }
 
 func (s *Server) loginUser(ctx *gin.Context) (err error) {
@@ -111,13 +120,36 @@ func (s *Server) loginUser(ctx *gin.Context) (err error) {
 	if err != nil {
 		return &ApiError{Status: http.StatusUnauthorized, Err: "login failed, not authorized"}
 	}
-	accessToken, err := s.tokenMaker.CreateToken(user.Username, s.config.TokenDuration)
+	// The refresh token starts the session, access tokens are issued within it
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, token.RefreshToken, uuid.Nil, s.config.RefreshTokenDuration)
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: "login failed, failed to generate refresh token"}
+	}
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, refreshPayload.SessionID, s.config.TokenDuration)
 	if err != nil {
 		return &ApiError{Status: http.StatusInternalServerError, Err: "login failed, failed to generate token"}
 	}
+
+	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.SessionID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpireAt,
+	})
+	if err != nil {
+		return &ApiError{Status: http.StatusInternalServerError, Err: "login failed, failed to create session"}
+	}
+
 	rsp := loginUserResponse{
-		AccessToken: accessToken,
-		User:        newUserResponse(user),
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpireAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpireAt,
+		User:                  newUserResponse(user),
 	}
 	ctx.JSON(http.StatusOK, rsp)
 	return
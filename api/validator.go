@@ -8,8 +8,15 @@ import (
 
 var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	if currency, ok := fl.Field().Interface().(string); ok {
-		// check for currentcy support
+		// Only currencies enabled in the registry are accepted
 		return util.IsSupportedCurrency(currency)
 	}
 	return false
 }
+
+var validRole validator.Func = func(fl validator.FieldLevel) bool {
+	if role, ok := fl.Field().Interface().(string); ok {
+		return util.IsSupportedRole(role)
+	}
+	return false
+}
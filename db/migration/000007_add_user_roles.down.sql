@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS "users" DROP CONSTRAINT IF EXISTS "users_role_check";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "role";
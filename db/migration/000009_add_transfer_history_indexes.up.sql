@@ -0,0 +1,3 @@
+-- Keyset pagination walks an account's transfers by descending id
+CREATE INDEX ON "transfers" ("from_account_id", "id");
+CREATE INDEX ON "transfers" ("to_account_id", "id");
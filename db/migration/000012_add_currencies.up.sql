@@ -0,0 +1,22 @@
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "numeric_code" int NOT NULL UNIQUE,
+  "minor_units" int NOT NULL,
+  "symbol" varchar NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT true,
+  CONSTRAINT "currencies_minor_units_check" CHECK ("minor_units" BETWEEN 0 AND 4)
+);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 alphabetic code';
+
+COMMENT ON COLUMN "currencies"."minor_units" IS 'decimal places of amounts, amounts are stored in minor units';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'new accounts and transfers are only accepted in enabled currencies';
+
+INSERT INTO "currencies" ("code", "numeric_code", "minor_units", "symbol") VALUES
+  ('USD', 840, 2, '$'),
+  ('CAD', 124, 2, 'CA$'),
+  ('EUR', 978, 2, '€'),
+  ('INR', 356, 2, '₹');
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
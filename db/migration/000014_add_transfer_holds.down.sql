@@ -0,0 +1,7 @@
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "held_amount";
+
+ALTER TABLE "transfers" DROP CONSTRAINT IF EXISTS "transfers_status_check";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "expires_at";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "status";
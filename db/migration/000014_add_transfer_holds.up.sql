@@ -0,0 +1,15 @@
+ALTER TABLE "transfers" ADD COLUMN "status" varchar NOT NULL DEFAULT 'posted';
+
+ALTER TABLE "transfers" ADD COLUMN "expires_at" timestamptz;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_status_check" CHECK ("status" IN ('pending', 'posted', 'voided', 'expired'));
+
+ALTER TABLE "accounts" ADD COLUMN "held_amount" bigint NOT NULL DEFAULT 0;
+
+CREATE INDEX ON "transfers" ("expires_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "transfers"."status" IS 'pending holds are posted by a capture, or voided or expired';
+
+COMMENT ON COLUMN "transfers"."expires_at" IS 'when a pending hold is released if not captured';
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'funds reserved by pending holds, the available balance is balance - held_amount';
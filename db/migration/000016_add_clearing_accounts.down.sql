@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS "transfers_external_reference_key";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "external_reference";
+
+-- Deposits and withdrawals have no place in the older schema. Only their clearing side is
+-- removed, the customer entries stay so account balances keep matching their entries
+DELETE FROM "entries"
+WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "is_clearing");
+
+DELETE FROM "transfers"
+WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "is_clearing")
+   OR "to_account_id" IN (SELECT "id" FROM "accounts" WHERE "is_clearing");
+
+DELETE FROM "accounts" WHERE "is_clearing";
+
+DROP INDEX IF EXISTS "accounts_clearing_currency_key";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "is_clearing";
+
+DELETE FROM "users" WHERE "username" = '_system';
@@ -0,0 +1,20 @@
+-- Owner of the clearing accounts. The name cannot be registered through the API and
+-- the empty password hash never matches, so nobody can log in as this user
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('_system', '', 'System', 'system@bank.invalid');
+
+ALTER TABLE "accounts" ADD COLUMN "is_clearing" boolean NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX "accounts_clearing_currency_key" ON "accounts" ("currency") WHERE "is_clearing";
+
+-- Clearing accounts mirror the cash held outside the bank, deposits take them below zero
+INSERT INTO "accounts" ("owner", "balance", "currency", "overdraft_limit", "is_clearing")
+SELECT '_system', 0, "code", 9223372036854775807, true FROM "currencies";
+
+ALTER TABLE "transfers" ADD COLUMN "external_reference" varchar;
+
+CREATE UNIQUE INDEX "transfers_external_reference_key" ON "transfers" ("external_reference");
+
+COMMENT ON COLUMN "accounts"."is_clearing" IS 'system account on the other side of deposits and withdrawals of its currency';
+
+COMMENT ON COLUMN "transfers"."external_reference" IS 'reference of the external movement of a deposit or withdrawal';
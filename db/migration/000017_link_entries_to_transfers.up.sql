@@ -0,0 +1,13 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint REFERENCES "transfers" ("id");
+
+ALTER TABLE "entries" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that posted the entry, empty for entries written before entries were linked';
+
+COMMENT ON COLUMN "entries"."description" IS 'memo shown on statements, taken from the transfer';
+
+COMMENT ON COLUMN "transfers"."description" IS 'memo given when the transfer was made';
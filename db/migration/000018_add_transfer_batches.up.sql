@@ -0,0 +1,45 @@
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "mode" varchar NOT NULL,
+  "status" varchar NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "item_count" int NOT NULL,
+  "succeeded_count" int NOT NULL,
+  "total_amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_batches_mode_check" CHECK ("mode" IN ('all_or_nothing', 'best_effort')),
+  CONSTRAINT "transfer_batches_status_check" CHECK ("status" IN ('completed', 'partial', 'failed'))
+);
+
+CREATE TABLE "transfer_batch_items" (
+  "batch_id" bigint NOT NULL,
+  "position" int NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "transfer_id" bigint,
+  "error" varchar,
+  PRIMARY KEY ("batch_id", "position")
+);
+
+CREATE INDEX ON "transfer_batches" ("owner");
+
+CREATE INDEX ON "transfer_batches" ("from_account_id");
+
+COMMENT ON COLUMN "transfer_batches"."owner" IS 'user who submitted the batch';
+
+COMMENT ON COLUMN "transfer_batches"."total_amount" IS 'sum of the items that were posted, debited from the source account at once';
+
+COMMENT ON COLUMN "transfer_batch_items"."to_account_id" IS 'as submitted, an unknown account fails the item';
+
+COMMENT ON COLUMN "transfer_batch_items"."transfer_id" IS 'set when the item was posted, error is set otherwise';
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
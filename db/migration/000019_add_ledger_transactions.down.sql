@@ -0,0 +1,3 @@
+ALTER TABLE "entries" DROP COLUMN IF EXISTS "ledger_transaction_id";
+
+DROP TABLE IF EXISTS "ledger_transactions";
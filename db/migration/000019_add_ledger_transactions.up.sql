@@ -0,0 +1,20 @@
+CREATE TABLE "ledger_transactions" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "entries" ADD COLUMN "ledger_transaction_id" bigint REFERENCES "ledger_transactions" ("id");
+
+CREATE INDEX ON "ledger_transactions" ("owner");
+
+CREATE INDEX ON "entries" ("ledger_transaction_id");
+
+COMMENT ON TABLE "ledger_transactions" IS 'multi-leg postings, their entries sum to zero per currency';
+
+COMMENT ON COLUMN "ledger_transactions"."owner" IS 'user who posted the transaction';
+
+COMMENT ON COLUMN "entries"."ledger_transaction_id" IS 'multi-leg transaction that posted the entry, entries of transfers have none';
+
+ALTER TABLE "ledger_transactions" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
@@ -0,0 +1,34 @@
+CREATE TABLE "limits" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint,
+  "owner" varchar,
+  "currency" varchar,
+  "kind" varchar NOT NULL,
+  "maximum" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "limits_kind_check" CHECK ("kind" IN ('single_transfer', 'daily_total', 'monthly_total', 'hourly_count')),
+  CONSTRAINT "limits_maximum_check" CHECK ("maximum" >= 0),
+  CONSTRAINT "limits_scope_check" CHECK (
+    ("account_id" IS NOT NULL AND "owner" IS NULL AND "currency" IS NULL)
+    OR ("account_id" IS NULL AND "owner" IS NOT NULL AND "currency" IS NOT NULL)
+  )
+);
+
+CREATE UNIQUE INDEX "limits_account_kind_key" ON "limits" ("account_id", "kind") WHERE "account_id" IS NOT NULL;
+
+CREATE UNIQUE INDEX "limits_owner_currency_kind_key" ON "limits" ("owner", "currency", "kind") WHERE "owner" IS NOT NULL;
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON TABLE "limits" IS 'caps on the transfers sent from one account, or from every account of a user in one currency';
+
+COMMENT ON COLUMN "limits"."kind" IS 'daily and monthly totals count from the start of the UTC day or month, hourly counts over the last hour';
+
+COMMENT ON COLUMN "limits"."maximum" IS 'an amount in the currency of the account, or a number of transfers for hourly_count';
+
+ALTER TABLE "limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "limits" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "limits" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
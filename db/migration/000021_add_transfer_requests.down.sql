@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "transfer_request_events";
+
+DROP TABLE IF EXISTS "transfer_requests";
+
+DROP TABLE IF EXISTS "account_cosigners";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "approval_threshold";
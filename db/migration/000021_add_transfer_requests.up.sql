@@ -0,0 +1,79 @@
+ALTER TABLE "accounts" ADD COLUMN "approval_threshold" bigint;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_approval_threshold_check" CHECK ("approval_threshold" >= 0);
+
+CREATE TABLE "account_cosigners" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+CREATE TABLE "transfer_requests" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "requested_by" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "expires_at" timestamptz NOT NULL,
+  "decided_by" varchar,
+  "decided_at" timestamptz,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_requests_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "transfer_requests_status_check" CHECK ("status" IN ('pending', 'approved', 'rejected', 'expired'))
+);
+
+CREATE TABLE "transfer_request_events" (
+  "id" bigserial PRIMARY KEY,
+  "request_id" bigint NOT NULL,
+  "action" varchar NOT NULL,
+  "actor" varchar,
+  "note" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_request_events_action_check" CHECK ("action" IN ('requested', 'approved', 'rejected', 'expired'))
+);
+
+CREATE INDEX ON "account_cosigners" ("username");
+
+CREATE INDEX ON "transfer_requests" ("from_account_id", "id");
+
+CREATE INDEX ON "transfer_requests" ("requested_by", "id");
+
+CREATE INDEX ON "transfer_requests" ("expires_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "transfer_request_events" ("request_id");
+
+COMMENT ON COLUMN "accounts"."approval_threshold" IS 'transfers above this amount wait for the approval of a co-signer, none when null';
+
+COMMENT ON TABLE "account_cosigners" IS 'users who approve the transfer requests of an account';
+
+COMMENT ON TABLE "transfer_requests" IS 'transfers above the approval threshold of their source account, posted once a co-signer approves';
+
+COMMENT ON COLUMN "transfer_requests"."decided_by" IS 'co-signer who approved or rejected the request, null while pending or once expired';
+
+COMMENT ON COLUMN "transfer_requests"."transfer_id" IS 'transfer posted on approval';
+
+COMMENT ON TABLE "transfer_request_events" IS 'audit trail of transfer requests, append only';
+
+COMMENT ON COLUMN "transfer_request_events"."actor" IS 'user who acted, null for expiry';
+
+ALTER TABLE "account_cosigners" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_cosigners" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("decided_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_request_events" ADD FOREIGN KEY ("request_id") REFERENCES "transfer_requests" ("id");
+
+ALTER TABLE "transfer_request_events" ADD FOREIGN KEY ("actor") REFERENCES "users" ("username");
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "idempotency_keys" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,3 @@
+ALTER TABLE "idempotency_keys" ADD COLUMN "status" int NOT NULL DEFAULT 200;
+
+COMMENT ON COLUMN "idempotency_keys"."status" IS 'HTTP status returned to the first request';
@@ -7,27 +7,102 @@ INSERT INTO accounts (
   $1, $2, $3
 ) RETURNING *;
 
+-- Clearing accounts may go as far negative as needed, they mirror money held outside the bank
+-- name: CreateClearingAccount :one
+INSERT INTO accounts (
+  owner,
+  balance,
+  currency,
+  overdraft_limit,
+  is_clearing
+) VALUES (
+  '_system', 0, $1, 9223372036854775807, true
+)
+ON CONFLICT (currency) WHERE is_clearing DO NOTHING
+RETURNING *;
+
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetClearingAccount :one
+SELECT * FROM accounts
+WHERE currency = $1 AND is_clearing
+LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListAccountsForUpdate :many
+-- Locks the accounts in ID order, like every transaction updating several accounts
+SELECT * FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+ORDER BY id
+FOR UPDATE;
+
 -- name: AddAccountBalance :one
+-- A debit only matches the row while the available balance stays within the overdraft limit
 UPDATE accounts
 set balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
+AND (sqlc.arg(amount) >= 0 OR balance - held_amount + sqlc.arg(amount) >= -overdraft_limit)
 RETURNING id, balance;
 
+-- name: HoldAccountFunds :one
+-- Only matches the row while the available balance covers the hold
+UPDATE accounts
+set held_amount = held_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+AND balance - held_amount - sqlc.arg(amount) >= -overdraft_limit
+RETURNING id, balance, held_amount;
+
+-- name: ReleaseAccountHold :one
+UPDATE accounts
+set held_amount = held_amount - sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING id, balance, held_amount;
+
+-- name: CaptureAccountHold :one
+-- Debits funds reserved by a hold, which were already checked against the overdraft limit
+UPDATE accounts
+set balance = balance - sqlc.arg(amount),
+  held_amount = held_amount - sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING id, balance, held_amount;
+
 -- name: UpdateAccount :exec
 UPDATE accounts
 set balance = $2
 WHERE id = $1;
 
+-- name: SetOverdraftLimit :one
+UPDATE accounts
+set overdraft_limit = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: SetApprovalThreshold :one
+UPDATE accounts
+set approval_threshold = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: SetAccountFrozen :one
+UPDATE accounts
+set is_frozen = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
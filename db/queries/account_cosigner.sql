@@ -0,0 +1,25 @@
+-- name: AddAccountCosigner :one
+INSERT INTO account_cosigners (
+  account_id,
+  username
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (account_id, username) DO UPDATE SET username = EXCLUDED.username
+RETURNING *;
+
+-- name: DeleteAccountCosigner :one
+DELETE FROM account_cosigners
+WHERE account_id = $1 AND username = $2
+RETURNING *;
+
+-- name: ListAccountCosigners :many
+SELECT * FROM account_cosigners
+WHERE account_id = $1
+ORDER BY username;
+
+-- name: IsAccountCosigner :one
+SELECT EXISTS (
+  SELECT 1 FROM account_cosigners
+  WHERE account_id = $1 AND username = $2
+);
@@ -1,9 +1,12 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id,
+  description,
+  ledger_transaction_id
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5
 ) RETURNING id;
 
  -- name: GetEntry :one
@@ -17,3 +20,53 @@ WHERE account_id = $1
 ORDER BY id
 LIMIT $2
 OFFSET $3;
+
+-- name: ListAccountEntries :many
+-- Entries from the first one created at or after created_from up to the first one
+-- created at or after created_to, cut on IDs like GetAccountBalanceAt. The balance
+-- before the page is start_balance when given, otherwise it is read once from the
+-- entries from the first row of the page on, the running balance only windows over
+-- the rows of the page
+WITH page AS (
+  SELECT * FROM entries
+  WHERE account_id = sqlc.arg(account_id)
+  AND id >= (
+    SELECT MIN(f.id) FROM entries f
+    WHERE f.account_id = sqlc.arg(account_id) AND f.created_at >= sqlc.arg(created_from)
+  )
+  AND id < COALESCE((
+    SELECT MIN(f.id) FROM entries f
+    WHERE f.account_id = sqlc.arg(account_id) AND f.created_at >= sqlc.arg(created_to)
+  ), 9223372036854775807)
+  AND (sqlc.narg(after_id)::bigint IS NULL OR id > sqlc.narg(after_id))
+  ORDER BY id
+  LIMIT sqlc.arg('limit')
+), start AS (
+  SELECT COALESCE(sqlc.narg(start_balance)::bigint, (
+    SELECT a.balance - COALESCE((
+      SELECT SUM(e.amount) FROM entries e
+      WHERE e.account_id = a.id AND e.id >= (SELECT MIN(id) FROM page)
+    ), 0)
+    FROM accounts a
+    WHERE a.id = sqlc.arg(account_id)
+  ))::bigint AS balance
+)
+SELECT p.id, p.account_id, p.amount, p.created_at, p.transfer_id, p.description, p.ledger_transaction_id,
+  (s.balance + SUM(p.amount) OVER (ORDER BY p.id))::bigint AS running_balance
+FROM page p
+CROSS JOIN start s
+ORDER BY p.id;
+
+-- name: GetAccountBalanceAt :one
+-- Balance before the first entry created at or after at. Entries are cut on their ID,
+-- the order of running balances, as a transaction retried under serializable isolation
+-- may commit an entry with a later ID but an earlier created_at than another
+SELECT (a.balance - COALESCE((
+  SELECT SUM(e.amount) FROM entries e
+  WHERE e.account_id = a.id AND e.id >= (
+    SELECT MIN(f.id) FROM entries f
+    WHERE f.account_id = a.id AND f.created_at >= sqlc.arg(at)
+  )
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = sqlc.arg(account_id);
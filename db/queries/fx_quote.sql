@@ -0,0 +1,26 @@
+-- name: CreateFxQuote :one
+INSERT INTO fx_quotes (
+  id,
+  username,
+  from_currency,
+  to_currency,
+  amount,
+  to_amount,
+  exchange_rate,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetFxQuote :one
+SELECT * FROM fx_quotes
+WHERE id = $1 LIMIT 1;
+
+-- name: ConsumeFxQuote :one
+-- Only matches a quote that is neither used nor expired, so each quote backs one transfer
+UPDATE fx_quotes
+set used_at = now()
+WHERE id = $1
+AND used_at IS NULL
+AND expires_at > now()
+RETURNING *;
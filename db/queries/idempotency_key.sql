@@ -0,0 +1,15 @@
+-- name: CreateIdempotencyKey :exec
+INSERT INTO idempotency_keys (
+  key,
+  username,
+  request_hash,
+  response,
+  status
+) VALUES (
+  $1, $2, $3, $4, $5
+);
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1
+AND key = $2 LIMIT 1;
@@ -0,0 +1,16 @@
+-- name: CreateLedgerTransaction :one
+INSERT INTO ledger_transactions (
+  owner,
+  description
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetLedgerTransaction :one
+SELECT * FROM ledger_transactions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListLedgerTransactionEntries :many
+SELECT * FROM entries
+WHERE ledger_transaction_id = $1
+ORDER BY id;
@@ -0,0 +1,61 @@
+-- name: SetAccountLimit :one
+INSERT INTO limits (
+  account_id,
+  kind,
+  maximum
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (account_id, kind) WHERE account_id IS NOT NULL
+DO UPDATE SET maximum = EXCLUDED.maximum, updated_at = now()
+RETURNING *;
+
+-- name: SetUserLimit :one
+INSERT INTO limits (
+  owner,
+  currency,
+  kind,
+  maximum
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (owner, currency, kind) WHERE owner IS NOT NULL
+DO UPDATE SET maximum = EXCLUDED.maximum, updated_at = now()
+RETURNING *;
+
+-- name: DeleteLimit :one
+DELETE FROM limits
+WHERE id = $1
+RETURNING *;
+
+-- name: ListAccountLimits :many
+-- Limits of the account itself and limits of its owner in its currency
+SELECT * FROM limits
+WHERE account_id = sqlc.arg(account_id)
+OR (owner = sqlc.arg(owner) AND currency = sqlc.arg(currency))
+ORDER BY id;
+
+-- name: GetOutgoingTransferTotals :one
+-- Totals of the transfers sent from one account, or from every account of an owner in
+-- one currency, within each limit window. Debit legs of multi-leg transactions count as
+-- transfers, cash withdrawals, reversals and released holds do not count
+SELECT
+  COALESCE(SUM(d.amount) FILTER (WHERE d.created_at >= sqlc.arg(day_start)::timestamptz), 0)::bigint AS daily_total,
+  COALESCE(SUM(d.amount) FILTER (WHERE d.created_at >= sqlc.arg(month_start)::timestamptz), 0)::bigint AS monthly_total,
+  COUNT(*) FILTER (WHERE d.created_at >= sqlc.arg(hour_start)::timestamptz) AS hourly_count
+FROM (
+  SELECT t.from_account_id AS account_id, t.amount, t.created_at
+  FROM transfers t
+  WHERE t.external_reference IS NULL
+  AND t.reversal_of IS NULL
+  AND t.status IN ('pending', 'posted')
+  UNION ALL
+  SELECT e.account_id, -e.amount AS amount, e.created_at
+  FROM entries e
+  WHERE e.ledger_transaction_id IS NOT NULL
+  AND e.amount < 0
+) d
+JOIN accounts a ON a.id = d.account_id
+WHERE (sqlc.narg(account_id)::bigint IS NULL OR d.account_id = sqlc.narg(account_id))
+AND (sqlc.narg(owner)::varchar IS NULL OR (a.owner = sqlc.narg(owner) AND a.currency = sqlc.narg(currency)))
+AND d.created_at >= LEAST(sqlc.arg(day_start)::timestamptz, sqlc.arg(month_start)::timestamptz, sqlc.arg(hour_start)::timestamptz);
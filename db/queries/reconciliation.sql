@@ -0,0 +1,64 @@
+-- name: ListAccountDrift :many
+-- Accounts whose balance differs from the sum of their entries, or whose entries
+-- differ from the net of their posted transfers and multi-leg transactions. A single
+-- statement reads one snapshot, so transfers committed meanwhile cannot show up as drift
+SELECT
+  a.id,
+  a.owner,
+  a.currency,
+  a.balance,
+  COALESCE(e.total, 0)::bigint AS entries_total,
+  (COALESCE(t_in.total, 0) - COALESCE(t_out.total, 0))::bigint AS transfers_net,
+  COALESCE(e.postings, 0)::bigint AS postings_net
+FROM accounts a
+LEFT JOIN (
+  SELECT account_id, SUM(amount) AS total,
+    SUM(amount) FILTER (WHERE ledger_transaction_id IS NOT NULL) AS postings
+  FROM entries GROUP BY account_id
+) e ON e.account_id = a.id
+LEFT JOIN (
+  SELECT to_account_id, SUM(to_amount) AS total FROM transfers
+  WHERE status = 'posted' GROUP BY to_account_id
+) t_in ON t_in.to_account_id = a.id
+LEFT JOIN (
+  SELECT from_account_id, SUM(amount) AS total FROM transfers
+  WHERE status = 'posted' GROUP BY from_account_id
+) t_out ON t_out.from_account_id = a.id
+WHERE a.balance <> COALESCE(e.total, 0)
+OR COALESCE(e.total, 0) <> COALESCE(t_in.total, 0) - COALESCE(t_out.total, 0) + COALESCE(e.postings, 0)
+ORDER BY a.id
+LIMIT $1;
+
+-- name: ListOrphanedTransfers :many
+-- Posted transfers without a debit entry on the source account or a credit entry on
+-- the destination account. Entries are written in the transaction that posts the
+-- transfer, so a matching entry cannot be older than the transfer. An entry linked to
+-- a transfer must be linked to this one, older unlinked entries are matched by amount
+SELECT id, from_account_id, to_account_id, amount, to_amount, has_debit, has_credit FROM (
+  SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.to_amount,
+    EXISTS (
+      SELECT 1 FROM entries e
+      WHERE e.account_id = t.from_account_id AND e.amount = -t.amount AND (e.transfer_id = t.id OR (e.transfer_id IS NULL AND e.ledger_transaction_id IS NULL AND e.created_at >= t.created_at))
+    ) AS has_debit,
+    EXISTS (
+      SELECT 1 FROM entries e
+      WHERE e.account_id = t.to_account_id AND e.amount = t.to_amount AND (e.transfer_id = t.id OR (e.transfer_id IS NULL AND e.ledger_transaction_id IS NULL AND e.created_at >= t.created_at))
+    ) AS has_credit
+  FROM transfers t
+  WHERE t.status = 'posted'
+) AS matched
+WHERE NOT has_debit OR NOT has_credit
+ORDER BY id
+LIMIT $1;
+
+-- name: ListUnbalancedTransfers :many
+-- Posted transfers whose legs cannot balance: a leg that is not positive, or a
+-- transfer between accounts of one currency crediting another amount than it debits
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.to_amount
+FROM transfers t
+JOIN accounts src ON src.id = t.from_account_id
+JOIN accounts dst ON dst.id = t.to_account_id
+WHERE t.status = 'posted'
+AND (t.amount <= 0 OR t.to_amount <= 0 OR (src.currency = dst.currency AND t.amount <> t.to_amount))
+ORDER BY t.id
+LIMIT $1;
@@ -0,0 +1,81 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  schedule,
+  next_run_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledTransfersByOwner :many
+SELECT * FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdateScheduledTransfer :one
+-- Editing a schedule starts its failure count over
+UPDATE scheduled_transfers
+set amount = $2,
+  schedule = $3,
+  next_run_at = $4,
+  enabled = $5,
+  failure_count = 0
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteScheduledTransfer :exec
+DELETE FROM scheduled_transfers
+WHERE id = $1;
+
+-- name: ListDueScheduledTransfers :many
+-- Rows locked by another worker are skipped rather than waited for
+SELECT * FROM scheduled_transfers
+WHERE enabled
+AND next_run_at <= sqlc.arg(now)
+AND (claimed_until IS NULL OR claimed_until <= sqlc.arg(now))
+ORDER BY next_run_at
+LIMIT sqlc.arg('limit')
+FOR UPDATE SKIP LOCKED;
+
+-- name: ClaimScheduledTransfer :exec
+UPDATE scheduled_transfers
+set claimed_until = $2
+WHERE id = $1;
+
+-- name: FinishScheduledTransferRun :one
+UPDATE scheduled_transfers
+set next_run_at = $2,
+  failure_count = $3,
+  enabled = $4,
+  last_run_at = now(),
+  claimed_until = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+  scheduled_transfer_id,
+  scheduled_for,
+  status,
+  transfer_id,
+  error
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
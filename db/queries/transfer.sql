@@ -2,11 +2,28 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate,
+  external_reference,
+  description
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6, $7
 ) RETURNING id;
 
+-- name: CreateHold :one
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  status,
+  expires_at,
+  description
+) VALUES (
+  sqlc.arg(from_account_id), sqlc.arg(to_account_id), sqlc.arg(amount), sqlc.arg(amount), 'pending', sqlc.arg(expires_at), sqlc.arg(description)
+) RETURNING *;
+
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
@@ -33,3 +50,73 @@ ORDER BY id
 LIMIT $3
 OFFSET $4
 ;
+
+-- name: ListTransfersByOwner :many
+SELECT t.* FROM transfers t
+WHERE (
+  t.from_account_id IN (SELECT id FROM accounts WHERE owner = sqlc.arg(owner))
+  OR t.to_account_id IN (SELECT id FROM accounts WHERE owner = sqlc.arg(owner))
+)
+AND (sqlc.narg(before_id)::bigint IS NULL OR t.id < sqlc.narg(before_id))
+ORDER BY t.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListAccountTransfers :many
+SELECT * FROM transfers
+WHERE (
+  (sqlc.arg(direction)::text IN ('out', 'both') AND from_account_id = sqlc.arg(account_id))
+  OR (sqlc.arg(direction)::text IN ('in', 'both') AND to_account_id = sqlc.arg(account_id))
+)
+AND (sqlc.narg(counterparty_id)::bigint IS NULL OR from_account_id = sqlc.narg(counterparty_id) OR to_account_id = sqlc.narg(counterparty_id))
+AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+AND (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: SettleHold :one
+-- Captures may post less than the held amount
+UPDATE transfers
+set status = $2,
+  amount = $3,
+  to_amount = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: ListExpiredHolds :many
+SELECT * FROM transfers
+WHERE status = 'pending'
+AND expires_at <= sqlc.arg(now)::timestamptz
+ORDER BY from_account_id, id
+LIMIT sqlc.arg('limit')
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateReversal :one
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  exchange_rate,
+  reversal_of,
+  description
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetReversedAmount :one
+-- Sums of the refunds of a transfer, amount is in the currency of its destination
+-- account and to_amount in the currency of its source account
+SELECT
+  COALESCE(SUM(amount), 0)::bigint AS amount,
+  COALESCE(SUM(to_amount), 0)::bigint AS to_amount
+FROM transfers
+WHERE reversal_of = $1;
@@ -0,0 +1,64 @@
+-- name: CreateTransferRequest :one
+INSERT INTO transfer_requests (
+  from_account_id,
+  to_account_id,
+  amount,
+  description,
+  requested_by,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetTransferRequest :one
+SELECT * FROM transfer_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferRequestForUpdate :one
+SELECT * FROM transfer_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListTransferRequests :many
+-- Requests made by the user or awaiting the approval of the user as co-signer
+SELECT * FROM transfer_requests
+WHERE (
+  requested_by = sqlc.arg(username)
+  OR from_account_id IN (SELECT account_id FROM account_cosigners WHERE username = sqlc.arg(username))
+)
+AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListExpiredTransferRequests :many
+SELECT * FROM transfer_requests
+WHERE status = 'pending'
+AND expires_at <= sqlc.arg(now)::timestamptz
+ORDER BY id
+LIMIT sqlc.arg('limit')
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: DecideTransferRequest :one
+UPDATE transfer_requests
+set status = $2,
+  decided_by = $3,
+  decided_at = now(),
+  transfer_id = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateTransferRequestEvent :one
+INSERT INTO transfer_request_events (
+  request_id,
+  action,
+  actor,
+  note
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListTransferRequestEvents :many
+SELECT * FROM transfer_request_events
+WHERE request_id = $1
+ORDER BY id;
@@ -7,12 +7,16 @@ package db
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 set balance = balance + $1
 WHERE id = $2
+AND ($1 >= 0 OR balance - held_amount + $1 >= -overdraft_limit)
 RETURNING id, balance
 `
 
@@ -26,6 +30,7 @@ type AddAccountBalanceRow struct {
 	Balance int64 `json:"balance"`
 }
 
+// A debit only matches the row while the available balance stays within the overdraft limit
 func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (AddAccountBalanceRow, error) {
 	row := q.db.QueryRowContext(ctx, addAccountBalance, arg.Amount, arg.ID)
 	var i AddAccountBalanceRow
@@ -33,6 +38,33 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 	return i, err
 }
 
+const captureAccountHold = `-- name: CaptureAccountHold :one
+UPDATE accounts
+set balance = balance - $1,
+  held_amount = held_amount - $1
+WHERE id = $2
+RETURNING id, balance, held_amount
+`
+
+type CaptureAccountHoldParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+type CaptureAccountHoldRow struct {
+	ID         int64 `json:"id"`
+	Balance    int64 `json:"balance"`
+	HeldAmount int64 `json:"held_amount"`
+}
+
+// Debits funds reserved by a hold, which were already checked against the overdraft limit
+func (q *Queries) CaptureAccountHold(ctx context.Context, arg CaptureAccountHoldParams) (CaptureAccountHoldRow, error) {
+	row := q.db.QueryRowContext(ctx, captureAccountHold, arg.Amount, arg.ID)
+	var i CaptureAccountHoldRow
+	err := row.Scan(&i.ID, &i.Balance, &i.HeldAmount)
+	return i, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   owner,
@@ -40,7 +72,7 @@ INSERT INTO accounts (
   currency
 ) VALUES (
   $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+) RETURNING id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold
 `
 
 type CreateAccountParams struct {
@@ -58,6 +90,44 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.IsFrozen,
+		&i.HeldAmount,
+		&i.IsClearing,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+const createClearingAccount = `-- name: CreateClearingAccount :one
+INSERT INTO accounts (
+  owner,
+  balance,
+  currency,
+  overdraft_limit,
+  is_clearing
+) VALUES (
+  '_system', 0, $1, 9223372036854775807, true
+)
+ON CONFLICT (currency) WHERE is_clearing DO NOTHING
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold
+`
+
+// Clearing accounts may go as far negative as needed, they mirror money held outside the bank
+func (q *Queries) CreateClearingAccount(ctx context.Context, currency string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createClearingAccount, currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.IsFrozen,
+		&i.HeldAmount,
+		&i.IsClearing,
+		&i.ApprovalThreshold,
 	)
 	return i, err
 }
@@ -73,7 +143,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -86,12 +156,68 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.IsFrozen,
+		&i.HeldAmount,
+		&i.IsClearing,
+		&i.ApprovalThreshold,
 	)
 	return i, err
 }
 
+const getClearingAccount = `-- name: GetClearingAccount :one
+SELECT id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold FROM accounts
+WHERE currency = $1 AND is_clearing
+LIMIT 1
+`
+
+func (q *Queries) GetClearingAccount(ctx context.Context, currency string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getClearingAccount, currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.IsFrozen,
+		&i.HeldAmount,
+		&i.IsClearing,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+const holdAccountFunds = `-- name: HoldAccountFunds :one
+UPDATE accounts
+set held_amount = held_amount + $1
+WHERE id = $2
+AND balance - held_amount - $1 >= -overdraft_limit
+RETURNING id, balance, held_amount
+`
+
+type HoldAccountFundsParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+type HoldAccountFundsRow struct {
+	ID         int64 `json:"id"`
+	Balance    int64 `json:"balance"`
+	HeldAmount int64 `json:"held_amount"`
+}
+
+// Only matches the row while the available balance covers the hold
+func (q *Queries) HoldAccountFunds(ctx context.Context, arg HoldAccountFundsParams) (HoldAccountFundsRow, error) {
+	row := q.db.QueryRowContext(ctx, holdAccountFunds, arg.Amount, arg.ID)
+	var i HoldAccountFundsRow
+	err := row.Scan(&i.ID, &i.Balance, &i.HeldAmount)
+	return i, err
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold FROM accounts
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -117,6 +243,101 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.IsFrozen,
+			&i.HeldAmount,
+			&i.IsClearing,
+			&i.ApprovalThreshold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByOwner = `-- name: ListAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold FROM accounts
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListAccountsByOwnerParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsByOwner, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.IsFrozen,
+			&i.HeldAmount,
+			&i.IsClearing,
+			&i.ApprovalThreshold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsForUpdate = `-- name: ListAccountsForUpdate :many
+SELECT id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold FROM accounts
+WHERE id = ANY($1::bigint[])
+ORDER BY id
+FOR UPDATE
+`
+
+// Locks the accounts in ID order, like every transaction updating several accounts
+func (q *Queries) ListAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsForUpdate, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.IsFrozen,
+			&i.HeldAmount,
+			&i.IsClearing,
+			&i.ApprovalThreshold,
 		); err != nil {
 			return nil, err
 		}
@@ -131,6 +352,121 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const releaseAccountHold = `-- name: ReleaseAccountHold :one
+UPDATE accounts
+set held_amount = held_amount - $1
+WHERE id = $2
+RETURNING id, balance, held_amount
+`
+
+type ReleaseAccountHoldParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+type ReleaseAccountHoldRow struct {
+	ID         int64 `json:"id"`
+	Balance    int64 `json:"balance"`
+	HeldAmount int64 `json:"held_amount"`
+}
+
+func (q *Queries) ReleaseAccountHold(ctx context.Context, arg ReleaseAccountHoldParams) (ReleaseAccountHoldRow, error) {
+	row := q.db.QueryRowContext(ctx, releaseAccountHold, arg.Amount, arg.ID)
+	var i ReleaseAccountHoldRow
+	err := row.Scan(&i.ID, &i.Balance, &i.HeldAmount)
+	return i, err
+}
+
+const setAccountFrozen = `-- name: SetAccountFrozen :one
+UPDATE accounts
+set is_frozen = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold
+`
+
+type SetAccountFrozenParams struct {
+	ID       int64 `json:"id"`
+	IsFrozen bool  `json:"is_frozen"`
+}
+
+func (q *Queries) SetAccountFrozen(ctx context.Context, arg SetAccountFrozenParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountFrozen, arg.ID, arg.IsFrozen)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.IsFrozen,
+		&i.HeldAmount,
+		&i.IsClearing,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+const setApprovalThreshold = `-- name: SetApprovalThreshold :one
+UPDATE accounts
+set approval_threshold = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold
+`
+
+type SetApprovalThresholdParams struct {
+	ID                int64         `json:"id"`
+	ApprovalThreshold sql.NullInt64 `json:"approval_threshold"`
+}
+
+func (q *Queries) SetApprovalThreshold(ctx context.Context, arg SetApprovalThresholdParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setApprovalThreshold, arg.ID, arg.ApprovalThreshold)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.IsFrozen,
+		&i.HeldAmount,
+		&i.IsClearing,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+const setOverdraftLimit = `-- name: SetOverdraftLimit :one
+UPDATE accounts
+set overdraft_limit = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, is_frozen, held_amount, is_clearing, approval_threshold
+`
+
+type SetOverdraftLimitParams struct {
+	ID             int64 `json:"id"`
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+func (q *Queries) SetOverdraftLimit(ctx context.Context, arg SetOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setOverdraftLimit, arg.ID, arg.OverdraftLimit)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.IsFrozen,
+		&i.HeldAmount,
+		&i.IsClearing,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :exec
 UPDATE accounts
 set balance = $2
@@ -10,10 +10,14 @@ import (
 )
 
 func createTestAccount(t *testing.T) (Account) {
+	return createTestAccountWithBalance(t, util.RandomAmount())
+}
+
+func createTestAccountWithBalance(t *testing.T, balance int64) Account {
 	user := createTestUser(t)
 	arg := CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  util.RandomAmount(),
+		Balance:  balance,
 		Currency: util.RandomCurrency(),
 	}
 	acc, err := testQueries.CreateAccount(context.Background(), arg)
@@ -59,6 +63,34 @@ func TestUpdateAccount(t *testing.T) {
 	require.Equal(t, acc.Currency, result.Currency)
 }
 
+func TestSetOverdraftLimit(t *testing.T) {
+	acc := createTestAccount(t)
+	require.Zero(t, acc.OverdraftLimit)
+
+	arg := SetOverdraftLimitParams{
+		ID:             acc.ID,
+		OverdraftLimit: util.RandomAmount(),
+	}
+	result, err := testQueries.SetOverdraftLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, acc.ID, result.ID)
+	require.Equal(t, acc.Balance, result.Balance)
+	require.Equal(t, arg.OverdraftLimit, result.OverdraftLimit)
+}
+
+func TestSetAccountFrozen(t *testing.T) {
+	acc := createTestAccount(t)
+	require.False(t, acc.IsFrozen)
+
+	result, err := testQueries.SetAccountFrozen(context.Background(), SetAccountFrozenParams{
+		ID:       acc.ID,
+		IsFrozen: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, acc.ID, result.ID)
+	require.True(t, result.IsFrozen)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	acc := createTestAccount(t)
 	err := testQueries.DeleteAccount(context.Background(), acc.ID)
@@ -84,3 +116,25 @@ func TestListAccounts(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 5, len(acc))
 }
+
+func TestListAccountsByOwner(t *testing.T) {
+	var lastAccount Account
+	for i := 0; i < 5; i++ {
+		lastAccount = createTestAccount(t)
+	}
+
+	arg := ListAccountsByOwnerParams{
+		Owner:  lastAccount.Owner,
+		Limit:  5,
+		Offset: 0,
+	}
+
+	accounts, err := testQueries.ListAccountsByOwner(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, accounts)
+
+	for _, account := range accounts {
+		require.NotEmpty(t, account)
+		require.Equal(t, lastAccount.Owner, account.Owner)
+	}
+}
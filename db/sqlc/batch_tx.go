@@ -0,0 +1,247 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Modes of a transfer batch
+const (
+	// Posts every item or none of them
+	BatchAllOrNothing = "all_or_nothing"
+	// Posts the items that can be posted and records why the others failed
+	BatchBestEffort = "best_effort"
+)
+
+// Outcomes of a transfer batch
+const (
+	BatchCompleted = "completed"
+	BatchPartial   = "partial"
+	BatchFailed    = "failed"
+)
+
+// Reasons a batch item cannot be posted, besides ErrInsufficientFunds and
+// ErrApprovalRequired
+var (
+	ErrBatchAccountNotFound = errors.New("destination account not found")
+	ErrBatchAccountFrozen   = errors.New("destination account is frozen")
+	ErrBatchClearingAccount = errors.New("destination account is a clearing account")
+	ErrBatchCurrency        = errors.New("destination account holds another currency")
+	ErrBatchSameAccount     = errors.New("destination account is the source account")
+)
+
+// Returned by an all or nothing batch when one of its items cannot be posted
+type BatchItemError struct {
+	Position int
+	Err      error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Position, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+type BatchTransferItem struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+	// Optional, defaults to the description of the batch
+	Description string `json:"description"`
+}
+
+type BatchTransferTxParams struct {
+	// User submitting the batch
+	Owner         string              `json:"owner"`
+	FromAccountID int64               `json:"from_account_id"`
+	Mode          string              `json:"mode"`
+	Description   string              `json:"description"`
+	Items         []BatchTransferItem `json:"items"`
+	// Optional, stores the result for replaying retries of the same request
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+type BatchTransferTxResult struct {
+	Batch       TransferBatch       `json:"batch"`
+	Items       []TransferBatchItem `json:"items"`
+	FromBalance int64               `json:"from_balance"`
+}
+
+// Posts a transfer from one source account to each item's destination, all in the
+// source currency. The source and destination accounts are locked once in ID order
+// and the source is debited once with the total of the posted items, while each item
+// keeps its own transfer and entries. The posted items count against the limits of the
+// source like as many transfers, and an item above its approval threshold fails. The
+// batch and the outcome of every item are recorded so the batch can be looked up later
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		ids := make([]int64, 0, len(arg.Items)+1)
+		ids = append(ids, arg.FromAccountID)
+		for _, item := range arg.Items {
+			ids = append(ids, item.ToAccountID)
+		}
+		locked, err := q.ListAccountsForUpdate(ctx, ids)
+		if err != nil {
+			return err
+		}
+		accounts := make(map[int64]Account, len(locked))
+		for _, account := range locked {
+			accounts[account.ID] = account
+		}
+		source, ok := accounts[arg.FromAccountID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		// Items are checked against the available balance left by the previous items
+		available := source.Balance - source.HeldAmount + source.OverdraftLimit
+		failures := make([]error, len(arg.Items))
+		var total int64
+		var succeeded int32
+		amounts := make([]int64, 0, len(arg.Items))
+		for i, item := range arg.Items {
+			failures[i] = batchItemFailure(source, accounts, item)
+			if failures[i] == nil {
+				failures[i] = checkApprovalThreshold(source, item.Amount)
+			}
+			if failures[i] == nil && item.Amount > available-total {
+				failures[i] = ErrInsufficientFunds
+			}
+			if failures[i] != nil {
+				if arg.Mode == BatchAllOrNothing {
+					return &BatchItemError{Position: i, Err: failures[i]}
+				}
+				continue
+			}
+			total += item.Amount
+			succeeded++
+			amounts = append(amounts, item.Amount)
+		}
+		// Limits apply to the posted items as a whole, a batch breaching one is rejected
+		// whatever its mode
+		if err = checkLimits(ctx, q, source, amounts, time.Now()); err != nil {
+			return err
+		}
+
+		status := BatchCompleted
+		if succeeded == 0 {
+			status = BatchFailed
+		} else if int(succeeded) < len(arg.Items) {
+			status = BatchPartial
+		}
+		result.Batch, err = q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+			Owner:          arg.Owner,
+			FromAccountID:  arg.FromAccountID,
+			Mode:           arg.Mode,
+			Status:         status,
+			Description:    arg.Description,
+			ItemCount:      int32(len(arg.Items)),
+			SucceededCount: succeeded,
+			TotalAmount:    total,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Items = make([]TransferBatchItem, len(arg.Items))
+		for i, item := range arg.Items {
+			itemArg := CreateTransferBatchItemParams{
+				BatchID:     result.Batch.ID,
+				Position:    int32(i),
+				ToAccountID: item.ToAccountID,
+				Amount:      item.Amount,
+				Description: item.Description,
+			}
+			if itemArg.Description == "" {
+				itemArg.Description = arg.Description
+			}
+			if failures[i] != nil {
+				itemArg.Error = sql.NullString{String: failures[i].Error(), Valid: true}
+			} else {
+				itemArg.TransferID, err = postBatchItem(ctx, q, arg.FromAccountID, itemArg)
+				if err != nil {
+					return err
+				}
+			}
+			result.Items[i], err = q.CreateTransferBatchItem(ctx, itemArg)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.FromBalance = source.Balance
+		if total > 0 {
+			debited, err := updateAccountBalance(ctx, q, arg.FromAccountID, -total)
+			if err != nil {
+				return err
+			}
+			result.FromBalance = debited.Balance
+		}
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+
+	return result, err
+}
+
+// Returns why the destination of an item cannot receive it, nil when it can
+func batchItemFailure(source Account, accounts map[int64]Account, item BatchTransferItem) error {
+	if item.ToAccountID == source.ID {
+		return ErrBatchSameAccount
+	}
+	to, ok := accounts[item.ToAccountID]
+	switch {
+	case !ok:
+		return ErrBatchAccountNotFound
+	case to.IsFrozen:
+		return ErrBatchAccountFrozen
+	case to.IsClearing:
+		return ErrBatchClearingAccount
+	case to.Currency != source.Currency:
+		return ErrBatchCurrency
+	}
+	return nil
+}
+
+// Creates the transfer and entries of an item and credits its destination. The
+// source is debited by the caller for all items at once
+func postBatchItem(ctx context.Context, q *Queries, fromAccountID int64, item CreateTransferBatchItemParams) (sql.NullInt64, error) {
+	transferID, err := q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: fromAccountID,
+		ToAccountID:   item.ToAccountID,
+		Amount:        item.Amount,
+		ToAmount:      item.Amount,
+		ExchangeRate:  "1",
+		Description:   item.Description,
+	})
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	link := sql.NullInt64{Int64: transferID, Valid: true}
+
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   fromAccountID,
+		Amount:      -item.Amount,
+		TransferID:  link,
+		Description: item.Description,
+	})
+	if err != nil {
+		return link, err
+	}
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   item.ToAccountID,
+		Amount:      item.Amount,
+		TransferID:  link,
+		Description: item.Description,
+	})
+	if err != nil {
+		return link, err
+	}
+	_, err = updateAccountBalance(ctx, q, item.ToAccountID, item.Amount)
+	return link, err
+}
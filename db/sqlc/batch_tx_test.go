@@ -0,0 +1,123 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTestAccountInCurrency(t *testing.T, balance int64, currency string) Account {
+	user := createTestUser(t)
+	acc, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return acc
+}
+
+func TestBatchTransferTxBestEffort(t *testing.T) {
+	store := NewStore(testDB)
+
+	source := createTestAccountInCurrency(t, 500, util.USD)
+	payee1 := createTestAccountInCurrency(t, 0, util.USD)
+	payee2 := createTestAccountInCurrency(t, 0, util.USD)
+	frozen := createTestAccountInCurrency(t, 0, util.USD)
+	_, err := testQueries.SetAccountFrozen(context.Background(), SetAccountFrozenParams{ID: frozen.ID, IsFrozen: true})
+	require.NoError(t, err)
+	euros := createTestAccountInCurrency(t, 0, util.EUR)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:         source.Owner,
+		FromAccountID: source.ID,
+		Mode:          BatchBestEffort,
+		Description:   "Payroll",
+		Items: []BatchTransferItem{
+			{ToAccountID: payee1.ID, Amount: 200, Description: "Bonus"},
+			{ToAccountID: frozen.ID, Amount: 10},
+			{ToAccountID: euros.ID, Amount: 10},
+			{ToAccountID: payee2.ID, Amount: 400},
+			{ToAccountID: payee2.ID, Amount: 300},
+			{ToAccountID: source.ID, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, BatchPartial, result.Batch.Status)
+	require.Equal(t, int32(6), result.Batch.ItemCount)
+	require.Equal(t, int32(2), result.Batch.SucceededCount)
+	require.Equal(t, int64(500), result.Batch.TotalAmount)
+	require.Equal(t, int64(0), result.FromBalance)
+
+	require.Len(t, result.Items, 6)
+	require.True(t, result.Items[0].TransferID.Valid)
+	require.Equal(t, "Bonus", result.Items[0].Description)
+	require.Equal(t, ErrBatchAccountFrozen.Error(), result.Items[1].Error.String)
+	require.Equal(t, ErrBatchCurrency.Error(), result.Items[2].Error.String)
+	require.Equal(t, ErrInsufficientFunds.Error(), result.Items[3].Error.String)
+	require.True(t, result.Items[4].TransferID.Valid)
+	require.Equal(t, "Payroll", result.Items[4].Description)
+	require.Equal(t, ErrBatchSameAccount.Error(), result.Items[5].Error.String)
+
+	transfer, err := store.GetTransfer(context.Background(), result.Items[4].TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, source.ID, transfer.FromAccountID)
+	require.Equal(t, payee2.ID, transfer.ToAccountID)
+	require.Equal(t, int64(300), transfer.Amount)
+
+	updated, err := store.GetAccount(context.Background(), payee2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(300), updated.Balance)
+
+	batch, err := store.GetTransferBatch(context.Background(), result.Batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Batch, batch)
+	items, err := store.ListTransferBatchItems(context.Background(), batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Items, items)
+}
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	store := NewStore(testDB)
+
+	source := createTestAccountInCurrency(t, 100, util.USD)
+	payee := createTestAccountInCurrency(t, 0, util.USD)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:         source.Owner,
+		FromAccountID: source.ID,
+		Mode:          BatchAllOrNothing,
+		Items: []BatchTransferItem{
+			{ToAccountID: payee.ID, Amount: 60},
+			{ToAccountID: payee.ID, Amount: 60},
+		},
+	})
+	var itemErr *BatchItemError
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Position)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// Nothing was posted
+	updated, err := store.GetAccount(context.Background(), source.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), updated.Balance)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Owner:         source.Owner,
+		FromAccountID: source.ID,
+		Mode:          BatchAllOrNothing,
+		Items: []BatchTransferItem{
+			{ToAccountID: payee.ID, Amount: 60},
+			{ToAccountID: payee.ID, Amount: 40},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, BatchCompleted, result.Batch.Status)
+	require.Equal(t, int64(0), result.FromBalance)
+
+	updated, err = store.GetAccount(context.Background(), payee.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), updated.Balance)
+}
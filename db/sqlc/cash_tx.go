@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Owner of the clearing accounts, also used for idempotency keys the bank records on
+// its own behalf. Usernames are alphanumeric, so no one can sign up or log in as it
+const SystemUser = "_system"
+
+// Returned when a deposit or withdrawal targets a clearing account
+var ErrClearingAccount = errors.New("cannot deposit to or withdraw from a clearing account")
+
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+	// Reference of the movement outside the bank, such as a wire or teller slip number
+	ExternalReference string `json:"external_reference"`
+	// Optional memo, defaults to the kind of movement and its external reference
+	Description string `json:"description"`
+	// Optional, stores the result for replaying retries of the same request
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// Credits an account with money received from outside the bank. The balancing debit
+// goes to the clearing account of the account's currency
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error) {
+	return store.cashTx(ctx, arg, true)
+}
+
+// Debits an account for money paid out of the bank, within its overdraft limit. The
+// balancing credit goes to the clearing account of the account's currency
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error) {
+	return store.cashTx(ctx, arg, false)
+}
+
+// Posts a deposit or withdrawal as a transfer between the account and its clearing account
+func (store *SQLStore) cashTx(ctx context.Context, arg CashTxParams, deposit bool) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if account.IsClearing {
+			return ErrClearingAccount
+		}
+		clearing, err := q.GetClearingAccount(ctx, account.Currency)
+		if err == sql.ErrNoRows {
+			// Currencies added to the registry after the clearing accounts were seeded get
+			// theirs on first use, a concurrent creation fails serialization and is retried
+			clearing, err = q.CreateClearingAccount(ctx, account.Currency)
+		}
+		if err != nil {
+			return err
+		}
+
+		description := arg.Description
+		if description == "" {
+			kind := "Deposit"
+			if !deposit {
+				kind = "Withdrawal"
+			}
+			description = kind + " " + arg.ExternalReference
+		}
+
+		transferArg := TransferTxParms{
+			FromAccountID:     clearing.ID,
+			ToAccountID:       account.ID,
+			Amount:            arg.Amount,
+			ExternalReference: sql.NullString{String: arg.ExternalReference, Valid: true},
+			Description:       description,
+			Idempotency:       arg.Idempotency,
+		}
+		if !deposit {
+			transferArg.FromAccountID, transferArg.ToAccountID = account.ID, clearing.ID
+		}
+		result, err = transfer(ctx, q, transferArg)
+		return err
+	})
+
+	return result, err
+}
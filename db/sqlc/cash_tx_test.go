@@ -0,0 +1,104 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositAndWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createTestAccountWithBalance(t, 0)
+	clearing, err := store.GetClearingAccount(context.Background(), account.Currency)
+	require.NoError(t, err)
+	require.True(t, clearing.IsClearing)
+
+	deposit, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID:         account.ID,
+		Amount:            500,
+		ExternalReference: "deposit-" + util.RandomString(12),
+	})
+	require.NoError(t, err)
+	require.Equal(t, clearing.ID, deposit.FromAccountID)
+	require.Equal(t, account.ID, deposit.ToAccountID)
+	require.Equal(t, int64(500), deposit.ToBalance)
+
+	transfer, err := store.GetTransfer(context.Background(), deposit.TransferID)
+	require.NoError(t, err)
+	require.True(t, transfer.ExternalReference.Valid)
+
+	withdrawal, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID:         account.ID,
+		Amount:            200,
+		ExternalReference: "withdrawal-" + util.RandomString(12),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, withdrawal.FromAccountID)
+	require.Equal(t, clearing.ID, withdrawal.ToAccountID)
+	require.Equal(t, int64(300), withdrawal.FromBalance)
+
+	// Withdrawals respect the overdraft limit
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID:         account.ID,
+		Amount:            301,
+		ExternalReference: "withdrawal-" + util.RandomString(12),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// An external reference can only be posted once
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID:         account.ID,
+		Amount:            500,
+		ExternalReference: transfer.ExternalReference.String,
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, "transfers_external_reference_key", pqErr.Constraint)
+
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID:         clearing.ID,
+		Amount:            500,
+		ExternalReference: "deposit-" + util.RandomString(12),
+	})
+	require.ErrorIs(t, err, ErrClearingAccount)
+}
+
+func TestDepositTxCreatesClearingAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	// A currency registered after the clearing accounts were seeded
+	currency := strings.ToUpper(util.RandomString(6))
+	_, err := testDB.ExecContext(context.Background(),
+		`INSERT INTO currencies (code, numeric_code, minor_units, symbol) VALUES ($1, $2, 2, $1)`,
+		currency, util.RandomInt(1000, 1000000000))
+	require.NoError(t, err)
+
+	_, err = store.GetClearingAccount(context.Background(), currency)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    createTestUser(t).Username,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+
+	deposit, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID:         account.ID,
+		Amount:            500,
+		ExternalReference: "deposit-" + util.RandomString(12),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(500), deposit.ToBalance)
+
+	clearing, err := store.GetClearingAccount(context.Background(), currency)
+	require.NoError(t, err)
+	require.True(t, clearing.IsClearing)
+	require.Equal(t, clearing.ID, deposit.FromAccountID)
+	require.Equal(t, int64(-500), deposit.FromBalance)
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	byCode := make(map[string]Currency, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+	for _, code := range []string{util.USD, util.CAD, util.EUR, util.INR} {
+		currency, ok := byCode[code]
+		require.True(t, ok, code)
+		require.True(t, currency.Enabled)
+		require.Equal(t, int32(2), currency.MinorUnits)
+	}
+	require.Equal(t, int32(840), byCode[util.USD].NumericCode)
+}
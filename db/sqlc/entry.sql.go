@@ -7,31 +7,72 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id,
+  description,
+  ledger_transaction_id
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5
 ) RETURNING id
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID           int64         `json:"account_id"`
+	Amount              int64         `json:"amount"`
+	TransferID          sql.NullInt64 `json:"transfer_id"`
+	Description         string        `json:"description"`
+	LedgerTransactionID sql.NullInt64 `json:"ledger_transaction_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.Description,
+		arg.LedgerTransactionID,
+	)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
 }
 
+const getAccountBalanceAt = `-- name: GetAccountBalanceAt :one
+SELECT (a.balance - COALESCE((
+  SELECT SUM(e.amount) FROM entries e
+  WHERE e.account_id = a.id AND e.id >= (
+    SELECT MIN(f.id) FROM entries f
+    WHERE f.account_id = a.id AND f.created_at >= $1
+  )
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = $2
+`
+
+type GetAccountBalanceAtParams struct {
+	At        time.Time `json:"at"`
+	AccountID int64     `json:"account_id"`
+}
+
+// Balance before the first entry created at or after at. Entries are cut on their ID,
+// the order of running balances, as a transaction retried under serializable isolation
+// may commit an entry with a later ID but an earlier created_at than another
+func (q *Queries) GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAccountBalanceAt, arg.At, arg.AccountID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, description, ledger_transaction_id FROM entries
 WHERE id = $1
 AND account_id = $2
 `
@@ -49,12 +90,111 @@ func (q *Queries) GetEntry(ctx context.Context, arg GetEntryParams) (Entry, erro
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Description,
+		&i.LedgerTransactionID,
 	)
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+WITH page AS (
+  SELECT id, account_id, amount, created_at, transfer_id, description, ledger_transaction_id FROM entries
+  WHERE account_id = $1
+  AND id >= (
+    SELECT MIN(f.id) FROM entries f
+    WHERE f.account_id = $1 AND f.created_at >= $2
+  )
+  AND id < COALESCE((
+    SELECT MIN(f.id) FROM entries f
+    WHERE f.account_id = $1 AND f.created_at >= $3
+  ), 9223372036854775807)
+  AND ($4::bigint IS NULL OR id > $4)
+  ORDER BY id
+  LIMIT $5
+), start AS (
+  SELECT COALESCE($6::bigint, (
+    SELECT a.balance - COALESCE((
+      SELECT SUM(e.amount) FROM entries e
+      WHERE e.account_id = a.id AND e.id >= (SELECT MIN(id) FROM page)
+    ), 0)
+    FROM accounts a
+    WHERE a.id = $1
+  ))::bigint AS balance
+)
+SELECT p.id, p.account_id, p.amount, p.created_at, p.transfer_id, p.description, p.ledger_transaction_id,
+  (s.balance + SUM(p.amount) OVER (ORDER BY p.id))::bigint AS running_balance
+FROM page p
+CROSS JOIN start s
+ORDER BY p.id
+`
+
+type ListAccountEntriesParams struct {
+	AccountID    int64         `json:"account_id"`
+	CreatedFrom  time.Time     `json:"created_from"`
+	CreatedTo    time.Time     `json:"created_to"`
+	AfterID      sql.NullInt64 `json:"after_id"`
+	Limit        int32         `json:"limit"`
+	StartBalance sql.NullInt64 `json:"start_balance"`
+}
+
+type ListAccountEntriesRow struct {
+	ID                  int64         `json:"id"`
+	AccountID           int64         `json:"account_id"`
+	Amount              int64         `json:"amount"`
+	CreatedAt           time.Time     `json:"created_at"`
+	TransferID          sql.NullInt64 `json:"transfer_id"`
+	Description         string        `json:"description"`
+	LedgerTransactionID sql.NullInt64 `json:"ledger_transaction_id"`
+	RunningBalance      int64         `json:"running_balance"`
+}
+
+// Entries from the first one created at or after created_from up to the first one
+// created at or after created_to, cut on IDs like GetAccountBalanceAt. The balance
+// before the page is start_balance when given, otherwise it is read once from the
+// entries from the first row of the page on, the running balance only windows over
+// the rows of the page
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterID,
+		arg.Limit,
+		arg.StartBalance,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesRow{}
+	for rows.Next() {
+		var i ListAccountEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Description,
+			&i.LedgerTransactionID,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, description, ledger_transaction_id FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2
@@ -81,6 +221,9 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.Description,
+			&i.LedgerTransactionID,
 		); err != nil {
 			return nil, err
 		}
@@ -3,7 +3,9 @@ package db
 import (
 	"bank/util"
 	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -43,6 +45,8 @@ func TestGetEntry(t *testing.T) {
 	require.Equal(t, arg.AccountID, entry.AccountID)
 	require.Equal(t, arg.Amount, entry.Amount)
 	require.NotEmpty(t, entry.CreatedAt)
+	require.False(t, entry.TransferID.Valid)
+	require.Empty(t, entry.Description)
 }
 
 func TestListEntries(t *testing.T) {
@@ -62,3 +66,120 @@ func TestListEntries(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 5, len(result))
 }
+
+func TestListAccountEntries(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
+	startTime := time.Now().Add(-time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferTx(context.Background(), TransferTxParms{
+			FromAccountID: acc1.ID,
+			ToAccountID:   acc2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+	endTime := time.Now().Add(time.Minute)
+
+	entries, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID:   acc1.ID,
+		CreatedFrom: startTime,
+		CreatedTo:   endTime,
+		Limit:       10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		require.Equal(t, int64(1000-10*(i+1)), entry.RunningBalance)
+	}
+
+	// A later page starts from the balance after the cursor
+	page, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID:   acc1.ID,
+		CreatedFrom: startTime,
+		CreatedTo:   endTime,
+		AfterID:     sql.NullInt64{Int64: entries[0].ID, Valid: true},
+		Limit:       1,
+	})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, entries[1].ID, page[0].ID)
+	require.Equal(t, int64(980), page[0].RunningBalance)
+
+	// A given start balance is used as is
+	page, err = testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID:    acc1.ID,
+		CreatedFrom:  startTime,
+		CreatedTo:    endTime,
+		AfterID:      sql.NullInt64{Int64: entries[0].ID, Valid: true},
+		Limit:        10,
+		StartBalance: sql.NullInt64{Int64: 990, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	require.Equal(t, int64(970), page[1].RunningBalance)
+
+	opening, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		At:        startTime,
+		AccountID: acc1.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), opening)
+
+	closing, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		At:        endTime,
+		AccountID: acc1.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(970), closing)
+}
+
+func TestListAccountEntriesOutOfOrder(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
+	startTime := time.Now().Add(-time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferTx(context.Background(), TransferTxParms{
+			FromAccountID: acc1.ID,
+			ToAccountID:   acc2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+	endTime := time.Now().Add(time.Minute)
+
+	// The last entry committed late, with a created_at before the statement period
+	_, err := testDB.ExecContext(context.Background(),
+		`UPDATE entries SET created_at = $1 WHERE id = (SELECT MAX(id) FROM entries WHERE account_id = $2)`,
+		startTime.Add(-time.Hour), acc1.ID)
+	require.NoError(t, err)
+
+	opening, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		At:        startTime,
+		AccountID: acc1.ID,
+	})
+	require.NoError(t, err)
+	closing, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		At:        endTime,
+		AccountID: acc1.ID,
+	})
+	require.NoError(t, err)
+	entries, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID:   acc1.ID,
+		CreatedFrom: startTime,
+		CreatedTo:   endTime,
+		Limit:       10,
+	})
+	require.NoError(t, err)
+
+	// Entries are cut on their ID, the opening balance and the lines add up to the closing one
+	require.Len(t, entries, 3)
+	require.Equal(t, int64(1000), opening)
+	require.Equal(t, opening+entries[0].Amount, entries[0].RunningBalance)
+	require.Equal(t, closing, entries[2].RunningBalance)
+	require.Equal(t, int64(970), closing)
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes of transactions that can succeed when run again
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// Defaults used for the zero values of TxConfig
+const (
+	defaultMaxTxRetries = 5
+	defaultTxRetryDelay = 10 * time.Millisecond
+	maxTxRetryDelay     = time.Second
+)
+
+// Isolation of transactions moving money. Concurrent transactions that could not
+// have run one after the other fail with a serialization failure and are retried
+var serializable = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+type TxConfig struct {
+	// Times a transaction failing with a serialization failure or a deadlock is run
+	// again before the error is returned. Negative disables retries
+	MaxRetries int
+	// Delay before the first retry, doubled for every further retry and jittered
+	RetryDelay time.Duration
+}
+
+// Counters of the transactions run by a store since it was created
+type TxStats struct {
+	// Transactions committed, whatever the number of attempts they took
+	Committed uint64 `json:"committed"`
+	// Attempts that failed with a serialization failure or a deadlock and were run again
+	Retries uint64 `json:"retries"`
+	// Transactions that still failed after MaxRetries retries
+	Exhausted uint64 `json:"exhausted"`
+}
+
+type txCounters struct {
+	committed atomic.Uint64
+	retries   atomic.Uint64
+	exhausted atomic.Uint64
+}
+
+// Returns the transaction counters of the store
+func (store *SQLStore) TxStats() TxStats {
+	return TxStats{
+		Committed: store.counters.committed.Load(),
+		Retries:   store.counters.retries.Load(),
+		Exhausted: store.counters.exhausted.Load(),
+	}
+}
+
+// Executes a function within a database transaction started with opts, nil for the
+// driver defaults. The function is run again in a new transaction when Postgres
+// aborts it with a serialization failure or a deadlock, so it must not keep state
+// from one attempt to the next other than what it overwrites
+func (store *SQLStore) execTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	for retry := 0; ; retry++ {
+		err := store.runTx(ctx, opts, fn)
+		if err == nil {
+			store.counters.committed.Add(1)
+			return nil
+		}
+		if !isRetryableTxError(err) {
+			return err
+		}
+		if retry >= store.config.MaxRetries {
+			store.counters.exhausted.Add(1)
+			return err
+		}
+		store.counters.retries.Add(1)
+
+		timer := time.NewTimer(store.retryDelay(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Runs fn in a single transaction, committed only when fn succeeds
+func (store *SQLStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	q := New(tx)
+	err = fn(q)
+	if err != nil {
+		// Both errors stay wrapped so a failed rollback does not hide a retryable error
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("TxErr: %w and RbErr: %w", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Returns a random delay between half and all of RetryDelay * 2^retry, at most one
+// second, so transactions that conflicted do not retry in lockstep
+func (store *SQLStore) retryDelay(retry int) time.Duration {
+	delay := store.config.RetryDelay
+	for i := 0; i < retry && delay < maxTxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxTxRetryDelay {
+		delay = maxTxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Reports whether err aborted a transaction that may succeed when run again
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryableTxError(t *testing.T) {
+	require.True(t, isRetryableTxError(&pq.Error{Code: serializationFailure}))
+	require.True(t, isRetryableTxError(fmt.Errorf("cannot transfer: %w", &pq.Error{Code: deadlockDetected})))
+	require.False(t, isRetryableTxError(&pq.Error{Code: "23505"}))
+	require.False(t, isRetryableTxError(ErrInsufficientFunds))
+}
+
+func TestExecTxRetry(t *testing.T) {
+	store := NewStoreWithConfig(testDB, TxConfig{MaxRetries: 2, RetryDelay: time.Millisecond}).(*SQLStore)
+
+	attempts := 0
+	err := store.execTx(context.Background(), serializable, func(q *Queries) error {
+		attempts++
+		if attempts < 3 {
+			return &pq.Error{Code: serializationFailure}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, TxStats{Committed: 1, Retries: 2}, store.TxStats())
+
+	attempts = 0
+	err = store.execTx(context.Background(), serializable, func(q *Queries) error {
+		attempts++
+		return &pq.Error{Code: deadlockDetected}
+	})
+	require.True(t, isRetryableTxError(err))
+	require.Equal(t, 3, attempts)
+	require.Equal(t, TxStats{Committed: 1, Retries: 4, Exhausted: 1}, store.TxStats())
+
+	// Other errors are returned at once
+	attempts = 0
+	err = store.execTx(context.Background(), serializable, func(q *Queries) error {
+		attempts++
+		return ErrInsufficientFunds
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+	require.Equal(t, 1, attempts)
+}
+
+func TestRunTxRollbackError(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+
+	// Ending the transaction early makes the rollback of runTx fail
+	err := store.runTx(context.Background(), serializable, func(q *Queries) error {
+		require.NoError(t, q.db.(*sql.Tx).Rollback())
+		return &pq.Error{Code: serializationFailure}
+	})
+	require.ErrorIs(t, err, sql.ErrTxDone)
+	require.True(t, isRetryableTxError(err))
+}
+
+func TestExecTxRetryDelay(t *testing.T) {
+	store := NewStoreWithConfig(testDB, TxConfig{RetryDelay: 10 * time.Millisecond}).(*SQLStore)
+
+	for retry := 0; retry < 4; retry++ {
+		max := 10 * time.Millisecond << retry
+		delay := store.retryDelay(retry)
+		require.GreaterOrEqual(t, delay, max/2)
+		require.LessOrEqual(t, delay, max)
+	}
+	require.LessOrEqual(t, store.retryDelay(30), maxTxRetryDelay)
+	require.LessOrEqual(t, store.retryDelay(62), maxTxRetryDelay)
+}
+
+// Transfers in both directions between the same accounts conflict under serializable
+// isolation, the retries must let every one of them through
+func TestTransferTxSerializableRetries(t *testing.T) {
+	store := NewStoreWithConfig(testDB, TxConfig{MaxRetries: 20, RetryDelay: time.Millisecond})
+
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
+
+	n := 10
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		from, to := acc1.ID, acc2.ID
+		if i%2 == 1 {
+			from, to = to, from
+		}
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParms{
+				FromAccountID: from,
+				ToAccountID:   to,
+				Amount:        10,
+			})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updated1, err := testQueries.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	updated2, err := testQueries.GetAccount(context.Background(), acc2.ID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance, updated1.Balance)
+	require.Equal(t, acc2.Balance, updated2.Balance)
+	require.Equal(t, uint64(n), store.TxStats().Committed)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestFxQuote(t *testing.T, username string, expiresAt time.Time) FxQuote {
+	arg := CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     username,
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Amount:       100,
+		ToAmount:     92,
+		ExchangeRate: "0.92",
+		ExpiresAt:    expiresAt,
+	}
+	quote, err := testQueries.CreateFxQuote(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, quote.ID)
+	require.Equal(t, arg.ToAmount, quote.ToAmount)
+	require.Equal(t, "0.92000000", quote.ExchangeRate)
+	require.False(t, quote.UsedAt.Valid)
+
+	return quote
+}
+
+func TestConsumeFxQuote(t *testing.T) {
+	user := createTestUser(t)
+	quote := createTestFxQuote(t, user.Username, time.Now().Add(time.Minute))
+
+	consumed, err := testQueries.ConsumeFxQuote(context.Background(), quote.ID)
+	require.NoError(t, err)
+	require.True(t, consumed.UsedAt.Valid)
+
+	// A quote backs a single transfer
+	_, err = testQueries.ConsumeFxQuote(context.Background(), quote.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := testQueries.GetFxQuote(context.Background(), quote.ID)
+	require.NoError(t, err)
+	require.True(t, got.UsedAt.Valid)
+}
+
+func TestConsumeExpiredFxQuote(t *testing.T) {
+	user := createTestUser(t)
+	quote := createTestFxQuote(t, user.Username, time.Now().Add(-time.Second))
+
+	_, err := testQueries.ConsumeFxQuote(context.Background(), quote.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Statuses of a transfer. Transfers made by TransferTx are posted immediately,
+// holds stay pending until they are captured, voided or expire
+const (
+	TransferPending = "pending"
+	TransferPosted  = "posted"
+	TransferVoided  = "voided"
+	TransferExpired = "expired"
+)
+
+var (
+	// Returned when capturing or voiding a transfer that is not a pending hold
+	ErrTransferNotPending = errors.New("transfer is not a pending hold")
+	// Returned when capturing a hold after it expired
+	ErrHoldExpired = errors.New("hold has expired")
+	// Returned when capturing more than the held amount
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the held amount")
+	// Returned when capturing a hold after one of its accounts was frozen
+	ErrHoldAccountFrozen = errors.New("account of the hold is frozen")
+)
+
+type HoldTxParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	// Optional memo, kept on the entries written when the hold is captured
+	Description string `json:"description"`
+	// Optional, stores the result for replaying retries of the same request
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+type HoldTxResult struct {
+	Transfer             Transfer `json:"transfer"`
+	FromAvailableBalance int64    `json:"from_available_balance"`
+}
+
+// Reserves Amount of the source account for a pending transfer. The available balance
+// drops immediately while the ledger balance only changes when the hold is captured.
+// The hold is checked against the limits and approval threshold of the source when it
+// is created, capturing it does not check them again
+func (store *SQLStore) HoldTx(ctx context.Context, arg HoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		from, err := q.GetAccount(ctx, arg.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if err = checkApprovalThreshold(from, arg.Amount); err != nil {
+			return err
+		}
+		if err = checkLimits(ctx, q, from, []int64{arg.Amount}, time.Now()); err != nil {
+			return err
+		}
+
+		account, err := q.HoldAccountFunds(ctx, HoldAccountFundsParams{
+			ID:     arg.FromAccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInsufficientFunds
+			}
+			return err
+		}
+		result.FromAvailableBalance = account.Balance - account.HeldAmount
+
+		result.Transfer, err = q.CreateHold(ctx, CreateHoldParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			ExpiresAt:     sql.NullTime{Time: arg.ExpiresAt, Valid: true},
+			Description:   arg.Description,
+		})
+		if err != nil {
+			return err
+		}
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+
+	return result, err
+}
+
+type CaptureTxParams struct {
+	TransferID int64
+	// Optional, captures the whole hold when zero and releases the rest otherwise
+	Amount int64
+	Now    time.Time
+}
+
+// Posts a pending hold, moving the captured amount between the accounts as TransferTx does.
+// Like transfers, a capture is refused once either account is frozen
+func (store *SQLStore) CaptureTx(ctx context.Context, arg CaptureTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		hold, err := pendingHold(ctx, q, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if !hold.ExpiresAt.Valid || !arg.Now.Before(hold.ExpiresAt.Time) {
+			return ErrHoldExpired
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+		// Either account may have been frozen since the hold was placed
+		for _, id := range []int64{hold.FromAccountID, hold.ToAccountID} {
+			account, err := q.GetAccount(ctx, id)
+			if err != nil {
+				return err
+			}
+			if account.IsFrozen {
+				return fmt.Errorf("%w: %d", ErrHoldAccountFrozen, id)
+			}
+		}
+
+		transfer, err := q.SettleHold(ctx, SettleHoldParams{
+			ID:     hold.ID,
+			Status: TransferPosted,
+			Amount: amount,
+		})
+		if err != nil {
+			return err
+		}
+		result.TransferID = transfer.ID
+		result.FromAccountID = transfer.FromAccountID
+		result.ToAccountID = transfer.ToAccountID
+		result.Amount = transfer.Amount
+		result.ToAmount = transfer.ToAmount
+		result.ExchangeRate = transfer.ExchangeRate
+		result.Description = transfer.Description
+
+		result.FromEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   transfer.FromAccountID,
+			Amount:      -amount,
+			TransferID:  sql.NullInt64{Int64: transfer.ID, Valid: true},
+			Description: transfer.Description,
+		})
+		if err != nil {
+			return err
+		}
+		result.ToEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   transfer.ToAccountID,
+			Amount:      amount,
+			TransferID:  sql.NullInt64{Int64: transfer.ID, Valid: true},
+			Description: transfer.Description,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Accounts are updated in ID order like in TransferTx to avoid deadlocks
+		debit := func() error {
+			result.FromBalance, err = captureHold(ctx, q, transfer.FromAccountID, amount, hold.Amount-amount)
+			return err
+		}
+		credit := func() error {
+			account, err := updateAccountBalance(ctx, q, transfer.ToAccountID, amount)
+			result.ToBalance = account.Balance
+			return err
+		}
+		if transfer.FromAccountID < transfer.ToAccountID {
+			if err = debit(); err != nil {
+				return err
+			}
+			return credit()
+		}
+		if err = credit(); err != nil {
+			return err
+		}
+		return debit()
+	})
+
+	return result, err
+}
+
+// Debits the captured amount from the reserved funds and releases the uncaptured rest
+func captureHold(ctx context.Context, q *Queries, accountID int64, captured int64, released int64) (int64, error) {
+	account, err := q.CaptureAccountHold(ctx, CaptureAccountHoldParams{
+		ID:     accountID,
+		Amount: captured,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if released == 0 {
+		return account.Balance, nil
+	}
+	rest, err := q.ReleaseAccountHold(ctx, ReleaseAccountHoldParams{
+		ID:     accountID,
+		Amount: released,
+	})
+	return rest.Balance, err
+}
+
+// Cancels a pending hold and releases its funds
+func (store *SQLStore) VoidTx(ctx context.Context, transferID int64) (Transfer, error) {
+	var transfer Transfer
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		hold, err := pendingHold(ctx, q, transferID)
+		if err != nil {
+			return err
+		}
+		transfer, err = releaseHold(ctx, q, hold, TransferVoided)
+		return err
+	})
+
+	return transfer, err
+}
+
+type ExpireHoldsTxParams struct {
+	Now   time.Time
+	Limit int32
+}
+
+// Releases up to Limit pending holds that expired before Now, skipping holds that
+// are being captured or voided
+func (store *SQLStore) ExpireHoldsTx(ctx context.Context, arg ExpireHoldsTxParams) ([]Transfer, error) {
+	var expired []Transfer
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		holds, err := q.ListExpiredHolds(ctx, ListExpiredHoldsParams{
+			Now:   arg.Now,
+			Limit: arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Holds are ordered by source account so accounts are locked in ID order
+		expired = make([]Transfer, 0, len(holds))
+		for _, hold := range holds {
+			transfer, err := releaseHold(ctx, q, hold, TransferExpired)
+			if err != nil {
+				return err
+			}
+			expired = append(expired, transfer)
+		}
+		return nil
+	})
+
+	return expired, err
+}
+
+// Locks the transfer and checks that it is a pending hold
+func pendingHold(ctx context.Context, q *Queries, transferID int64) (Transfer, error) {
+	hold, err := q.GetTransferForUpdate(ctx, transferID)
+	if err != nil {
+		return Transfer{}, err
+	}
+	if hold.Status != TransferPending {
+		return Transfer{}, ErrTransferNotPending
+	}
+	return hold, nil
+}
+
+func releaseHold(ctx context.Context, q *Queries, hold Transfer, status string) (Transfer, error) {
+	_, err := q.ReleaseAccountHold(ctx, ReleaseAccountHoldParams{
+		ID:     hold.FromAccountID,
+		Amount: hold.Amount,
+	})
+	if err != nil {
+		return Transfer{}, err
+	}
+	return q.SettleHold(ctx, SettleHoldParams{
+		ID:     hold.ID,
+		Status: status,
+		Amount: hold.Amount,
+	})
+}
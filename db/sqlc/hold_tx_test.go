@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTestHold(t *testing.T, store Store, from, to Account, amount int64, expiresAt time.Time) Transfer {
+	result, err := store.HoldTx(context.Background(), HoldTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferPending, result.Transfer.Status)
+	require.Equal(t, from.Balance-amount, result.FromAvailableBalance)
+	return result.Transfer
+}
+
+func TestHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+	createTestHold(t, store, acc1, acc2, 70, time.Now().Add(time.Hour))
+
+	// The ledger balance is unchanged, only the available balance dropped
+	account, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.Balance)
+	require.Equal(t, int64(70), account.HeldAmount)
+
+	// Held funds can neither be held again nor transferred
+	_, err = store.HoldTx(context.Background(), HoldTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        40,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        40,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCaptureTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+	hold := createTestHold(t, store, acc1, acc2, 70, time.Now().Add(time.Hour))
+
+	_, err := store.CaptureTx(context.Background(), CaptureTxParams{TransferID: hold.ID, Amount: 80, Now: time.Now()})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	result, err := store.CaptureTx(context.Background(), CaptureTxParams{TransferID: hold.ID, Amount: 50, Now: time.Now()})
+	require.NoError(t, err)
+	require.Equal(t, hold.ID, result.TransferID)
+	require.Equal(t, int64(50), result.Amount)
+	require.Equal(t, int64(50), result.FromBalance)
+	require.Equal(t, int64(150), result.ToBalance)
+
+	// The uncaptured rest of the hold is released
+	account, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), account.Balance)
+	require.Zero(t, account.HeldAmount)
+
+	transfer, err := store.GetTransfer(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferPosted, transfer.Status)
+	require.Equal(t, int64(50), transfer.Amount)
+
+	_, err = store.CaptureTx(context.Background(), CaptureTxParams{TransferID: hold.ID, Now: time.Now()})
+	require.ErrorIs(t, err, ErrTransferNotPending)
+}
+
+func TestCaptureTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+	hold := createTestHold(t, store, acc1, acc2, 70, time.Now().Add(time.Minute))
+
+	_, err := store.CaptureTx(context.Background(), CaptureTxParams{TransferID: hold.ID, Now: time.Now().Add(time.Hour)})
+	require.ErrorIs(t, err, ErrHoldExpired)
+}
+
+func TestCaptureTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+	hold := createTestHold(t, store, acc1, acc2, 70, time.Now().Add(time.Hour))
+
+	_, err := store.SetAccountFrozen(context.Background(), SetAccountFrozenParams{ID: acc2.ID, IsFrozen: true})
+	require.NoError(t, err)
+	_, err = store.CaptureTx(context.Background(), CaptureTxParams{TransferID: hold.ID, Now: time.Now()})
+	require.ErrorIs(t, err, ErrHoldAccountFrozen)
+
+	// The refused capture left the hold pending, it can still be voided
+	transfer, err := store.VoidTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferVoided, transfer.Status)
+}
+
+func TestVoidTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+	hold := createTestHold(t, store, acc1, acc2, 70, time.Now().Add(time.Hour))
+
+	transfer, err := store.VoidTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferVoided, transfer.Status)
+
+	account, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.Balance)
+	require.Zero(t, account.HeldAmount)
+
+	_, err = store.VoidTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrTransferNotPending)
+}
+
+func TestExpireHoldsTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+	hold := createTestHold(t, store, acc1, acc2, 70, time.Now().Add(time.Minute))
+
+	expired, err := store.ExpireHoldsTx(context.Background(), ExpireHoldsTxParams{
+		Now:   time.Now().Add(time.Hour),
+		Limit: 1000,
+	})
+	require.NoError(t, err)
+
+	found := false
+	for _, transfer := range expired {
+		require.Equal(t, TransferExpired, transfer.Status)
+		found = found || transfer.ID == hold.ID
+	}
+	require.True(t, found)
+
+	account, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.Balance)
+	require.Zero(t, account.HeldAmount)
+}
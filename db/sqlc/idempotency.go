@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Identifies a client request so retries can replay the stored response
+// instead of executing the request again
+type IdempotencyParams struct {
+	Key         string
+	Username    string
+	RequestHash string
+	// HTTP status replayed with the stored response, 200 when zero
+	Status int32
+}
+
+// Stores the response of an idempotent request in the same transaction that produced it.
+// A concurrent request with the same key fails the insert and rolls back its own work
+func saveIdempotentResponse(ctx context.Context, q *Queries, params *IdempotencyParams, response interface{}) error {
+	if params == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	status := params.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Key:         params.Key,
+		Username:    params.Username,
+		RequestHash: params.RequestHash,
+		Response:    data,
+		Status:      status,
+	})
+}
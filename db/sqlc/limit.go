@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Kinds of transfer limits
+const (
+	LimitSingleTransfer = "single_transfer"
+	LimitDailyTotal     = "daily_total"
+	LimitMonthlyTotal   = "monthly_total"
+	LimitHourlyCount    = "hourly_count"
+)
+
+// Returned when a transfer, batch, multi-leg transaction or hold would breach a limit
+// of a debited account or of the owner of that account
+type LimitExceededError struct {
+	Limit Limit
+	// Amount, or number of transfers, still allowed in the window of the limit
+	Remaining int64
+}
+
+func (e *LimitExceededError) Error() string {
+	scope := fmt.Sprintf("account %d", e.Limit.AccountID.Int64)
+	if e.Limit.Owner.Valid {
+		scope = fmt.Sprintf("user %s in %s", e.Limit.Owner.String, e.Limit.Currency.String)
+	}
+	switch e.Limit.Kind {
+	case LimitSingleTransfer:
+		return fmt.Sprintf("%s limit of %s exceeded: at most %d per transfer", e.Limit.Kind, scope, e.Limit.Maximum)
+	case LimitHourlyCount:
+		return fmt.Sprintf("%s limit of %s exceeded: %d of %d transfers left", e.Limit.Kind, scope, e.Remaining, e.Limit.Maximum)
+	}
+	return fmt.Sprintf("%s limit of %s exceeded: %d of %d left", e.Limit.Kind, scope, e.Remaining, e.Limit.Maximum)
+}
+
+// A limit with what was sent in its current window
+type LimitUsage struct {
+	Limit Limit `json:"limit"`
+	// Amount, or number of transfers for hourly_count, sent in the window
+	Used      int64 `json:"used"`
+	Remaining int64 `json:"remaining"`
+}
+
+// Start of the windows of daily, monthly and hourly limits at now. Days and months
+// start in UTC, the hourly window is the last hour
+func limitWindows(now time.Time) (day time.Time, month time.Time, hour time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month, now.Add(-time.Hour)
+}
+
+// Lists the limits applying to transfers out of the account, its own and those of its
+// owner in its currency, with their usage at now
+func ListLimitUsage(ctx context.Context, q Querier, account Account, now time.Time) ([]LimitUsage, error) {
+	limits, err := q.ListAccountLimits(ctx, ListAccountLimitsParams{
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		Owner:     sql.NullString{String: account.Owner, Valid: true},
+		Currency:  sql.NullString{String: account.Currency, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	day, month, hour := limitWindows(now)
+	// Totals of the account and of its owner, each read once
+	totals := make(map[bool]GetOutgoingTransferTotalsRow, 2)
+	usages := make([]LimitUsage, len(limits))
+	for i, limit := range limits {
+		ofOwner := limit.Owner.Valid
+		total, ok := totals[ofOwner]
+		if !ok && limit.Kind != LimitSingleTransfer {
+			arg := GetOutgoingTransferTotalsParams{DayStart: day, MonthStart: month, HourStart: hour}
+			if ofOwner {
+				arg.Owner = limit.Owner
+				arg.Currency = limit.Currency
+			} else {
+				arg.AccountID = limit.AccountID
+			}
+			total, err = q.GetOutgoingTransferTotals(ctx, arg)
+			if err != nil {
+				return nil, err
+			}
+			totals[ofOwner] = total
+		}
+
+		usages[i].Limit = limit
+		switch limit.Kind {
+		case LimitDailyTotal:
+			usages[i].Used = total.DailyTotal
+		case LimitMonthlyTotal:
+			usages[i].Used = total.MonthlyTotal
+		case LimitHourlyCount:
+			usages[i].Used = total.HourlyCount
+		}
+		if usages[i].Used < limit.Maximum {
+			usages[i].Remaining = limit.Maximum - usages[i].Used
+		}
+	}
+	return usages, nil
+}
+
+// Checks transfers of amounts out of the account against every limit applying to them,
+// each amount against single_transfer and all of them together against the others.
+// Run in a serializable transaction, concurrent transfers cannot both use the last of
+// an allowance since one of them is retried
+func checkLimits(ctx context.Context, q Querier, account Account, amounts []int64, now time.Time) error {
+	if len(amounts) == 0 {
+		return nil
+	}
+	var total, largest int64
+	for _, amount := range amounts {
+		total += amount
+		if amount > largest {
+			largest = amount
+		}
+	}
+
+	usages, err := ListLimitUsage(ctx, q, account, now)
+	if err != nil {
+		return err
+	}
+	for _, usage := range usages {
+		var exceeded bool
+		switch usage.Limit.Kind {
+		case LimitSingleTransfer:
+			exceeded = largest > usage.Limit.Maximum
+		case LimitHourlyCount:
+			exceeded = usage.Remaining < int64(len(amounts))
+		default:
+			exceeded = total > usage.Remaining
+		}
+		if exceeded {
+			return &LimitExceededError{Limit: usage.Limit, Remaining: usage.Remaining}
+		}
+	}
+	return nil
+}
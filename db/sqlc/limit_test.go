@@ -0,0 +1,258 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitWindows(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 0, 30, 0, 0, time.UTC)
+	day, month, hour := limitWindows(now)
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), day)
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), month)
+	require.Equal(t, time.Date(2024, time.February, 29, 23, 30, 0, 0, time.UTC), hour)
+}
+
+func TestSetAccountLimit(t *testing.T) {
+	account := createTestAccountInCurrency(t, 0, util.USD)
+	arg := SetAccountLimitParams{
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		Kind:      LimitDailyTotal,
+		Maximum:   100,
+	}
+	limit, err := testQueries.SetAccountLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), limit.Maximum)
+
+	// Setting the same kind again replaces the limit
+	arg.Maximum = 200
+	updated, err := testQueries.SetAccountLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, limit.ID, updated.ID)
+	require.Equal(t, int64(200), updated.Maximum)
+
+	deleted, err := testQueries.DeleteLimit(context.Background(), limit.ID)
+	require.NoError(t, err)
+	require.Equal(t, limit.ID, deleted.ID)
+	_, err = testQueries.DeleteLimit(context.Background(), limit.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferTxAccountLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountInCurrency(t, 1000, util.USD)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	setLimit := func(kind string, maximum int64) {
+		_, err := testQueries.SetAccountLimit(context.Background(), SetAccountLimitParams{
+			AccountID: sql.NullInt64{Int64: from.ID, Valid: true},
+			Kind:      kind,
+			Maximum:   maximum,
+		})
+		require.NoError(t, err)
+	}
+	setLimit(LimitSingleTransfer, 100)
+	setLimit(LimitDailyTotal, 250)
+	setLimit(LimitHourlyCount, 3)
+
+	send := func(amount int64) error {
+		_, err := store.TransferTx(context.Background(), TransferTxParms{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+		})
+		return err
+	}
+
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, send(101), &limitErr)
+	require.Equal(t, LimitSingleTransfer, limitErr.Limit.Kind)
+
+	require.NoError(t, send(100))
+	require.NoError(t, send(100))
+
+	require.ErrorAs(t, send(60), &limitErr)
+	require.Equal(t, LimitDailyTotal, limitErr.Limit.Kind)
+	require.Equal(t, int64(50), limitErr.Remaining)
+
+	require.NoError(t, send(50))
+	require.ErrorAs(t, send(1), &limitErr)
+	require.Contains(t, []string{LimitDailyTotal, LimitHourlyCount}, limitErr.Limit.Kind)
+
+	usages, err := ListLimitUsage(context.Background(), store, from, time.Now())
+	require.NoError(t, err)
+	require.Len(t, usages, 3)
+	require.Equal(t, int64(250), usages[1].Used)
+	require.Zero(t, usages[1].Remaining)
+	require.Equal(t, int64(3), usages[2].Used)
+	require.Zero(t, usages[2].Remaining)
+
+	// Rejected transfers were not posted
+	updated, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(750), updated.Balance)
+}
+
+func TestTransferTxUserLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createTestAccountInCurrency(t, 1000, util.USD)
+	account2, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account1.Owner,
+		Balance:  1000,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	euros := createTestAccountInCurrency(t, 0, util.EUR)
+
+	_, err = testQueries.SetUserLimit(context.Background(), SetUserLimitParams{
+		Owner:    sql.NullString{String: account1.Owner, Valid: true},
+		Currency: sql.NullString{String: util.USD, Valid: true},
+		Kind:     LimitMonthlyTotal,
+		Maximum:  300,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParms{FromAccountID: account1.ID, ToAccountID: to.ID, Amount: 200})
+	require.NoError(t, err)
+
+	var limitErr *LimitExceededError
+	_, err = store.TransferTx(context.Background(), TransferTxParms{FromAccountID: account1.ID, ToAccountID: to.ID, Amount: 200})
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, account1.Owner, limitErr.Limit.Owner.String)
+	require.Equal(t, int64(100), limitErr.Remaining)
+
+	// The limit only covers accounts of the user in its currency
+	_, err = store.TransferTx(context.Background(), TransferTxParms{FromAccountID: account2.ID, ToAccountID: euros.ID, Amount: 500})
+	require.NoError(t, err)
+}
+
+func TestBatchTransferTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountInCurrency(t, 1000, util.USD)
+	to1 := createTestAccountInCurrency(t, 0, util.USD)
+	to2 := createTestAccountInCurrency(t, 0, util.USD)
+	_, err := testQueries.SetAccountLimit(context.Background(), SetAccountLimitParams{
+		AccountID: sql.NullInt64{Int64: from.ID, Valid: true},
+		Kind:      LimitDailyTotal,
+		Maximum:   250,
+	})
+	require.NoError(t, err)
+
+	batch := func(mode string, amount1, amount2 int64) error {
+		_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+			Owner:         from.Owner,
+			FromAccountID: from.ID,
+			Mode:          mode,
+			Items: []BatchTransferItem{
+				{ToAccountID: to1.ID, Amount: amount1},
+				{ToAccountID: to2.ID, Amount: amount2},
+			},
+		})
+		return err
+	}
+
+	// Items within the limit one by one breach it together, whatever the mode
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, batch(BatchBestEffort, 200, 100), &limitErr)
+	require.Equal(t, LimitDailyTotal, limitErr.Limit.Kind)
+	require.ErrorAs(t, batch(BatchAllOrNothing, 200, 100), &limitErr)
+
+	require.NoError(t, batch(BatchAllOrNothing, 100, 100))
+	require.ErrorAs(t, batch(BatchAllOrNothing, 30, 30), &limitErr)
+	require.Equal(t, int64(50), limitErr.Remaining)
+}
+
+func TestPostTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	payer := createTestAccountInCurrency(t, 1000, util.USD)
+	payee1 := createTestAccountInCurrency(t, 0, util.USD)
+	payee2 := createTestAccountInCurrency(t, 0, util.USD)
+	setLimit := func(kind string, maximum int64) {
+		_, err := testQueries.SetAccountLimit(context.Background(), SetAccountLimitParams{
+			AccountID: sql.NullInt64{Int64: payer.ID, Valid: true},
+			Kind:      kind,
+			Maximum:   maximum,
+		})
+		require.NoError(t, err)
+	}
+	setLimit(LimitSingleTransfer, 100)
+	setLimit(LimitDailyTotal, 250)
+
+	post := func(debit1, debit2 int64) error {
+		_, err := store.PostTx(context.Background(), PostTxParams{
+			Owner: payer.Owner,
+			Legs: []PostingLeg{
+				{AccountID: payer.ID, Amount: -debit1},
+				{AccountID: payer.ID, Amount: -debit2},
+				{AccountID: payee1.ID, Amount: debit1},
+				{AccountID: payee2.ID, Amount: debit2},
+			},
+		})
+		return err
+	}
+
+	// Each debit leg is checked on its own against single_transfer
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, post(150, 10), &limitErr)
+	require.Equal(t, LimitSingleTransfer, limitErr.Limit.Kind)
+
+	require.NoError(t, post(100, 100))
+
+	// Debits of earlier transactions count towards the totals
+	require.ErrorAs(t, post(40, 40), &limitErr)
+	require.Equal(t, LimitDailyTotal, limitErr.Limit.Kind)
+	require.Equal(t, int64(50), limitErr.Remaining)
+
+	// Only the posted debit legs are used up
+	usages, err := ListLimitUsage(context.Background(), store, payer, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, int64(200), usages[1].Used)
+}
+
+func TestHoldTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountInCurrency(t, 1000, util.USD)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	_, err := testQueries.SetAccountLimit(context.Background(), SetAccountLimitParams{
+		AccountID: sql.NullInt64{Int64: from.ID, Valid: true},
+		Kind:      LimitDailyTotal,
+		Maximum:   250,
+	})
+	require.NoError(t, err)
+
+	hold := func(amount int64) (HoldTxResult, error) {
+		return store.HoldTx(context.Background(), HoldTxParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+			ExpiresAt:     time.Now().Add(time.Hour),
+		})
+	}
+
+	var limitErr *LimitExceededError
+	_, err = hold(300)
+	require.ErrorAs(t, err, &limitErr)
+
+	// A pending hold uses the allowance when it is created
+	held, err := hold(200)
+	require.NoError(t, err)
+	_, err = hold(100)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, int64(50), limitErr.Remaining)
+
+	// Capturing it does not count it again
+	_, err = store.CaptureTx(context.Background(), CaptureTxParams{TransferID: held.Transfer.ID, Now: time.Now()})
+	require.NoError(t, err)
+	_, err = hold(50)
+	require.NoError(t, err)
+}
@@ -5,7 +5,11 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
@@ -14,6 +18,34 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// frozen accounts cannot send or receive transfers
+	IsFrozen bool `json:"is_frozen"`
+	// funds reserved by pending holds, the available balance is balance - held_amount
+	HeldAmount int64 `json:"held_amount"`
+	// system account on the other side of deposits and withdrawals of its currency
+	IsClearing bool `json:"is_clearing"`
+	// transfers above this amount wait for the approval of a co-signer, none when null
+	ApprovalThreshold sql.NullInt64 `json:"approval_threshold"`
+}
+
+// users who approve the transfer requests of an account
+type AccountCosigner struct {
+	AccountID int64     `json:"account_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Currency struct {
+	// ISO 4217 alphabetic code
+	Code        string `json:"code"`
+	NumericCode int32  `json:"numeric_code"`
+	// decimal places of amounts, amounts are stored in minor units
+	MinorUnits int32  `json:"minor_units"`
+	Symbol     string `json:"symbol"`
+	// new accounts and transfers are only accepted in enabled currencies
+	Enabled bool `json:"enabled"`
 }
 
 type Entry struct {
@@ -22,6 +54,103 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer that posted the entry, empty for entries written before entries were linked
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// memo shown on statements, taken from the transfer
+	Description string `json:"description"`
+	// multi-leg transaction that posted the entry, entries of transfers have none
+	LedgerTransactionID sql.NullInt64 `json:"ledger_transaction_id"`
+}
+
+type FxQuote struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Amount       int64     `json:"amount"`
+	ToAmount     int64     `json:"to_amount"`
+	ExchangeRate string    `json:"exchange_rate"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	// set when a transfer consumes the quote
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Key      string `json:"key"`
+	Username string `json:"username"`
+	// sha256 of the route and request body
+	RequestHash string `json:"request_hash"`
+	// result returned to the first request
+	Response  json.RawMessage `json:"response"`
+	CreatedAt time.Time       `json:"created_at"`
+	// HTTP status returned to the first request
+	Status int32 `json:"status"`
+}
+
+// multi-leg postings, their entries sum to zero per currency
+type LedgerTransaction struct {
+	ID int64 `json:"id"`
+	// user who posted the transaction
+	Owner       string    `json:"owner"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// caps on the transfers sent from one account, or from every account of a user in one currency
+type Limit struct {
+	ID        int64          `json:"id"`
+	AccountID sql.NullInt64  `json:"account_id"`
+	Owner     sql.NullString `json:"owner"`
+	Currency  sql.NullString `json:"currency"`
+	// daily and monthly totals count from the start of the UTC day or month, hourly counts over the last hour
+	Kind string `json:"kind"`
+	// an amount in the currency of the account, or a number of transfers for hourly_count
+	Maximum   int64     `json:"maximum"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// cron expression or @every interval
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+	Enabled   bool      `json:"enabled"`
+	// consecutive failed runs, the schedule is disabled after too many
+	FailureCount int32 `json:"failure_count"`
+	// set while a worker executes the due run
+	ClaimedUntil sql.NullTime `json:"claimed_until"`
+	LastRunAt    sql.NullTime `json:"last_run_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64 `json:"id"`
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	// next_run_at of the schedule when the run was claimed
+	ScheduledFor time.Time      `json:"scheduled_for"`
+	Status       string         `json:"status"`
+	TransferID   sql.NullInt64  `json:"transfer_id"`
+	Error        sql.NullString `json:"error"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type Session struct {
+	// id of the refresh token payload
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfer struct {
@@ -31,6 +160,76 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// amount credited in the currency of the destination account
+	ToAmount int64 `json:"to_amount"`
+	// destination units per source unit applied to amount
+	ExchangeRate string `json:"exchange_rate"`
+	// pending holds are posted by a capture, or voided or expired
+	Status string `json:"status"`
+	// when a pending hold is released if not captured
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	// transfer refunded by this compensating transfer
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
+	// reference of the external movement of a deposit or withdrawal
+	ExternalReference sql.NullString `json:"external_reference"`
+	// memo given when the transfer was made
+	Description string `json:"description"`
+}
+
+type TransferBatch struct {
+	ID int64 `json:"id"`
+	// user who submitted the batch
+	Owner          string `json:"owner"`
+	FromAccountID  int64  `json:"from_account_id"`
+	Mode           string `json:"mode"`
+	Status         string `json:"status"`
+	Description    string `json:"description"`
+	ItemCount      int32  `json:"item_count"`
+	SucceededCount int32  `json:"succeeded_count"`
+	// sum of the items that were posted, debited from the source account at once
+	TotalAmount int64     `json:"total_amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TransferBatchItem struct {
+	BatchID  int64 `json:"batch_id"`
+	Position int32 `json:"position"`
+	// as submitted, an unknown account fails the item
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+	// set when the item was posted, error is set otherwise
+	TransferID sql.NullInt64  `json:"transfer_id"`
+	Error      sql.NullString `json:"error"`
+}
+
+// transfers above the approval threshold of their source account, posted once a co-signer approves
+type TransferRequest struct {
+	ID            int64     `json:"id"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Description   string    `json:"description"`
+	RequestedBy   string    `json:"requested_by"`
+	Status        string    `json:"status"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	// co-signer who approved or rejected the request, null while pending or once expired
+	DecidedBy sql.NullString `json:"decided_by"`
+	DecidedAt sql.NullTime   `json:"decided_at"`
+	// transfer posted on approval
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// audit trail of transfer requests, append only
+type TransferRequestEvent struct {
+	ID        int64  `json:"id"`
+	RequestID int64  `json:"request_id"`
+	Action    string `json:"action"`
+	// user who acted, null for expiry
+	Actor     sql.NullString `json:"actor"`
+	Note      string         `json:"note"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 type User struct {
@@ -40,4 +239,5 @@ type User struct {
 	Email            string    `json:"email"`
 	PaswordChangedAt time.Time `json:"pasword_changed_at"`
 	CreatedAt        time.Time `json:"created_at"`
+	Role             string    `json:"role"`
 }
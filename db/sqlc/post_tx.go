@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// Returned when a transaction has fewer than two legs or a leg of zero amount
+	ErrInvalidPosting = errors.New("a transaction needs at least two legs of non-zero amount")
+	// Returned when the legs of a transaction do not sum to zero in one of its currencies
+	ErrUnbalancedPosting = errors.New("legs must sum to zero per currency")
+	// Returned when a leg names an account that does not exist
+	ErrPostingAccountNotFound = errors.New("account not found")
+)
+
+// One entry of a multi-leg transaction
+type PostingLeg struct {
+	AccountID int64 `json:"account_id"`
+	// In the currency of the account, negative for a debit and positive for a credit
+	Amount int64 `json:"amount"`
+	// Optional, defaults to the description of the transaction
+	Description string `json:"description"`
+}
+
+type PostTxParams struct {
+	// User posting the transaction
+	Owner       string       `json:"owner"`
+	Description string       `json:"description"`
+	Legs        []PostingLeg `json:"legs"`
+	// Optional, stores the result for replaying retries of the same request
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+type PostedLeg struct {
+	EntryID   int64 `json:"entry_id"`
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+	// Balance of the account once every leg of the transaction is applied
+	Balance int64 `json:"balance"`
+}
+
+type PostTxResult struct {
+	Transaction LedgerTransaction `json:"transaction"`
+	Legs        []PostedLeg       `json:"legs"`
+}
+
+// Posts a transaction made of any number of entries, such as one debit split across
+// several recipients, under a parent ledger_transactions row. The legs must sum to
+// zero in each currency. Like moveMoney, accounts are updated in ascending ID order,
+// after all of them were locked in that order, so concurrent postings cannot deadlock.
+// An account debited by several legs is checked against its overdraft limit once for
+// the net of its legs, while each debit leg counts against its limits and approval
+// threshold as a transfer
+func (store *SQLStore) PostTx(ctx context.Context, arg PostTxParams) (PostTxResult, error) {
+	var result PostTxResult
+
+	if len(arg.Legs) < 2 {
+		return result, ErrInvalidPosting
+	}
+	ids := make([]int64, len(arg.Legs))
+	for i, leg := range arg.Legs {
+		if leg.Amount == 0 {
+			return result, ErrInvalidPosting
+		}
+		ids[i] = leg.AccountID
+	}
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		locked, err := q.ListAccountsForUpdate(ctx, ids)
+		if err != nil {
+			return err
+		}
+		accounts := make(map[int64]Account, len(locked))
+		for _, account := range locked {
+			accounts[account.ID] = account
+		}
+
+		sums := make(map[string]int64)
+		nets := make(map[int64]int64, len(locked))
+		debits := make(map[int64][]int64, len(locked))
+		for _, leg := range arg.Legs {
+			account, ok := accounts[leg.AccountID]
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrPostingAccountNotFound, leg.AccountID)
+			}
+			sums[account.Currency] += leg.Amount
+			nets[account.ID] += leg.Amount
+			if leg.Amount < 0 {
+				debits[account.ID] = append(debits[account.ID], -leg.Amount)
+			}
+		}
+		for currency, sum := range sums {
+			if sum != 0 {
+				return fmt.Errorf("%w, %s legs sum to %d", ErrUnbalancedPosting, currency, sum)
+			}
+		}
+		now := time.Now()
+		for _, account := range locked {
+			if err = checkApprovalThreshold(account, debits[account.ID]...); err != nil {
+				return err
+			}
+			if err = checkLimits(ctx, q, account, debits[account.ID], now); err != nil {
+				return err
+			}
+		}
+
+		result.Transaction, err = q.CreateLedgerTransaction(ctx, CreateLedgerTransactionParams{
+			Owner:       arg.Owner,
+			Description: arg.Description,
+		})
+		if err != nil {
+			return err
+		}
+		link := sql.NullInt64{Int64: result.Transaction.ID, Valid: true}
+
+		result.Legs = make([]PostedLeg, len(arg.Legs))
+		for i, leg := range arg.Legs {
+			description := leg.Description
+			if description == "" {
+				description = arg.Description
+			}
+			result.Legs[i].EntryID, err = q.CreateEntry(ctx, CreateEntryParams{
+				AccountID:           leg.AccountID,
+				Amount:              leg.Amount,
+				Description:         description,
+				LedgerTransactionID: link,
+			})
+			if err != nil {
+				return err
+			}
+			result.Legs[i].AccountID = leg.AccountID
+			result.Legs[i].Amount = leg.Amount
+		}
+
+		// locked is ordered by ID
+		balances := make(map[int64]int64, len(locked))
+		for _, account := range locked {
+			balances[account.ID] = account.Balance
+			if nets[account.ID] == 0 {
+				continue
+			}
+			updated, err := updateAccountBalance(ctx, q, account.ID, nets[account.ID])
+			if err != nil {
+				return err
+			}
+			balances[account.ID] = updated.Balance
+		}
+		for i := range result.Legs {
+			result.Legs[i].Balance = balances[result.Legs[i].AccountID]
+		}
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+
+	return result, err
+}
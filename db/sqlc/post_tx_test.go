@@ -0,0 +1,143 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	payer := createTestAccountInCurrency(t, 100, util.USD)
+	payee1 := createTestAccountInCurrency(t, 0, util.USD)
+	payee2 := createTestAccountInCurrency(t, 0, util.USD)
+
+	result, err := store.PostTx(context.Background(), PostTxParams{
+		Owner:       payer.Owner,
+		Description: "Dinner",
+		Legs: []PostingLeg{
+			{AccountID: payee2.ID, Amount: 30},
+			{AccountID: payer.ID, Amount: -100},
+			{AccountID: payee1.ID, Amount: 70, Description: "Your share"},
+		},
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.Transaction.ID)
+	require.Equal(t, "Dinner", result.Transaction.Description)
+	require.Len(t, result.Legs, 3)
+	require.Equal(t, int64(30), result.Legs[0].Balance)
+	require.Equal(t, int64(0), result.Legs[1].Balance)
+	require.Equal(t, int64(70), result.Legs[2].Balance)
+
+	entries, err := store.ListLedgerTransactionEntries(context.Background(), sql.NullInt64{Int64: result.Transaction.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	var sum int64
+	for i, entry := range entries {
+		require.Equal(t, result.Legs[i].EntryID, entry.ID)
+		require.False(t, entry.TransferID.Valid)
+		sum += entry.Amount
+	}
+	require.Zero(t, sum)
+	require.Equal(t, "Dinner", entries[0].Description)
+	require.Equal(t, "Your share", entries[2].Description)
+}
+
+func TestPostTxRejected(t *testing.T) {
+	store := NewStore(testDB)
+
+	payer := createTestAccountInCurrency(t, 100, util.USD)
+	payee := createTestAccountInCurrency(t, 0, util.USD)
+	euros := createTestAccountInCurrency(t, 0, util.EUR)
+
+	testCases := []struct {
+		name string
+		legs []PostingLeg
+		err  error
+	}{
+		{
+			name: "SingleLeg",
+			legs: []PostingLeg{{AccountID: payer.ID, Amount: -10}},
+			err:  ErrInvalidPosting,
+		},
+		{
+			name: "ZeroAmount",
+			legs: []PostingLeg{{AccountID: payer.ID, Amount: 0}, {AccountID: payee.ID, Amount: 0}},
+			err:  ErrInvalidPosting,
+		},
+		{
+			name: "Unbalanced",
+			legs: []PostingLeg{{AccountID: payer.ID, Amount: -10}, {AccountID: payee.ID, Amount: 9}},
+			err:  ErrUnbalancedPosting,
+		},
+		{
+			name: "UnbalancedPerCurrency",
+			legs: []PostingLeg{{AccountID: payer.ID, Amount: -10}, {AccountID: euros.ID, Amount: 10}},
+			err:  ErrUnbalancedPosting,
+		},
+		{
+			name: "AccountNotFound",
+			legs: []PostingLeg{{AccountID: payer.ID, Amount: -10}, {AccountID: -1, Amount: 10}},
+			err:  ErrPostingAccountNotFound,
+		},
+		{
+			name: "InsufficientFunds",
+			legs: []PostingLeg{{AccountID: payer.ID, Amount: -60}, {AccountID: payer.ID, Amount: -60}, {AccountID: payee.ID, Amount: 120}},
+			err:  ErrInsufficientFunds,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.PostTx(context.Background(), PostTxParams{Owner: payer.Owner, Legs: tc.legs})
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	updated, err := store.GetAccount(context.Background(), payer.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), updated.Balance)
+}
+
+// Postings in opposite directions between the same accounts lock them in the same order
+func TestPostTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountInCurrency(t, 1000, util.USD)
+	acc2 := createTestAccountInCurrency(t, 1000, util.USD)
+	acc3 := createTestAccountInCurrency(t, 1000, util.USD)
+
+	n := 10
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		legs := []PostingLeg{
+			{AccountID: acc1.ID, Amount: -20},
+			{AccountID: acc2.ID, Amount: 10},
+			{AccountID: acc3.ID, Amount: 10},
+		}
+		if i%2 == 1 {
+			legs = []PostingLeg{
+				{AccountID: acc3.ID, Amount: -10},
+				{AccountID: acc2.ID, Amount: -10},
+				{AccountID: acc1.ID, Amount: 20},
+			}
+		}
+		go func() {
+			_, err := store.PostTx(context.Background(), PostTxParams{Owner: acc1.Owner, Legs: legs})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	for _, acc := range []Account{acc1, acc2, acc3} {
+		updated, err := store.GetAccount(context.Background(), acc.ID)
+		require.NoError(t, err)
+		require.Equal(t, acc.Balance, updated.Balance)
+	}
+}
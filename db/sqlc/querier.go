@@ -6,25 +6,130 @@ package db
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
+	// A debit only matches the row while the available balance stays within the overdraft limit
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (AddAccountBalanceRow, error)
+	AddAccountCosigner(ctx context.Context, arg AddAccountCosignerParams) (AccountCosigner, error)
+	BlockSession(ctx context.Context, arg BlockSessionParams) (int64, error)
+	BlockUserSessions(ctx context.Context, username string) ([]uuid.UUID, error)
+	// Debits funds reserved by a hold, which were already checked against the overdraft limit
+	CaptureAccountHold(ctx context.Context, arg CaptureAccountHoldParams) (CaptureAccountHoldRow, error)
+	ClaimScheduledTransfer(ctx context.Context, arg ClaimScheduledTransferParams) error
+	// Only matches a quote that is neither used nor expired, so each quote backs one transfer
+	ConsumeFxQuote(ctx context.Context, id uuid.UUID) (FxQuote, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	// Clearing accounts may go as far negative as needed, they mirror money held outside the bank
+	CreateClearingAccount(ctx context.Context, currency string) (Account, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (int64, error)
+	CreateFxQuote(ctx context.Context, arg CreateFxQuoteParams) (FxQuote, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Transfer, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) error
+	CreateLedgerTransaction(ctx context.Context, arg CreateLedgerTransactionParams) (LedgerTransaction, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error)
+	CreateReversal(ctx context.Context, arg CreateReversalParams) (Transfer, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (int64, error)
+	CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error)
+	CreateTransferBatchItem(ctx context.Context, arg CreateTransferBatchItemParams) (TransferBatchItem, error)
+	CreateTransferRequest(ctx context.Context, arg CreateTransferRequestParams) (TransferRequest, error)
+	CreateTransferRequestEvent(ctx context.Context, arg CreateTransferRequestEventParams) (TransferRequestEvent, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DecideTransferRequest(ctx context.Context, arg DecideTransferRequestParams) (TransferRequest, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountCosigner(ctx context.Context, arg DeleteAccountCosignerParams) (AccountCosigner, error)
+	DeleteLimit(ctx context.Context, id int64) (Limit, error)
+	DeleteScheduledTransfer(ctx context.Context, id int64) error
+	FinishScheduledTransferRun(ctx context.Context, arg FinishScheduledTransferRunParams) (ScheduledTransfer, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	// Balance before the first entry created at or after at. Entries are cut on their ID,
+	// the order of running balances, as a transaction retried under serializable isolation
+	// may commit an entry with a later ID but an earlier created_at than another
+	GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error)
+	GetClearingAccount(ctx context.Context, currency string) (Account, error)
 	GetEntry(ctx context.Context, arg GetEntryParams) (Entry, error)
+	GetFxQuote(ctx context.Context, id uuid.UUID) (FxQuote, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLedgerTransaction(ctx context.Context, id int64) (LedgerTransaction, error)
+	// Sums of the refunds of a transfer, amount is in the currency of its destination
+	// account and to_amount in the currency of its source account
+	// Totals of the transfers sent from one account, or from every account of an owner in
+	// one currency, within each limit window. Debit legs of multi-leg transactions count as
+	// transfers, cash withdrawals, reversals and released holds do not count
+	GetOutgoingTransferTotals(ctx context.Context, arg GetOutgoingTransferTotalsParams) (GetOutgoingTransferTotalsRow, error)
+	GetReversedAmount(ctx context.Context, reversalOf sql.NullInt64) (GetReversedAmountRow, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSessionBlocked(ctx context.Context, id uuid.UUID) (bool, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferRequest(ctx context.Context, id int64) (TransferRequest, error)
+	GetTransferRequestForUpdate(ctx context.Context, id int64) (TransferRequest, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	// Only matches the row while the available balance covers the hold
+	HoldAccountFunds(ctx context.Context, arg HoldAccountFundsParams) (HoldAccountFundsRow, error)
+	IsAccountCosigner(ctx context.Context, arg IsAccountCosignerParams) (bool, error)
+	ListAccountCosigners(ctx context.Context, accountID int64) ([]AccountCosigner, error)
+	// Accounts whose balance differs from the sum of their entries, or whose entries
+	// differ from the net of their posted transfers and multi-leg transactions. A single
+	// statement reads one snapshot, so transfers committed meanwhile cannot show up as drift
+	ListAccountDrift(ctx context.Context, limit int32) ([]ListAccountDriftRow, error)
+	// Entries from the first one created at or after created_from up to the first one
+	// created at or after created_to, cut on IDs like GetAccountBalanceAt. The balance
+	// before the page is start_balance when given, otherwise it is read once from the
+	// entries from the first row of the page on, the running balance only windows over
+	// the rows of the page
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error)
+	// Limits of the account itself and limits of its owner in its currency
+	ListAccountLimits(ctx context.Context, arg ListAccountLimitsParams) ([]Limit, error)
+	ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error)
+	// Locks the accounts in ID order, like every transaction updating several accounts
+	ListAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
+	// Rows locked by another worker are skipped rather than waited for
+	ListDueScheduledTransfers(ctx context.Context, arg ListDueScheduledTransfersParams) ([]ScheduledTransfer, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListExpiredHolds(ctx context.Context, arg ListExpiredHoldsParams) ([]Transfer, error)
+	ListExpiredTransferRequests(ctx context.Context, arg ListExpiredTransferRequestsParams) ([]TransferRequest, error)
+	ListLedgerTransactionEntries(ctx context.Context, ledgerTransactionID sql.NullInt64) ([]Entry, error)
+	// Posted transfers without a debit entry on the source account or a credit entry on
+	// the destination account. Entries are written in the transaction that posts the
+	// transfer, so a matching entry cannot be older than the transfer. An entry linked to
+	// a transfer must be linked to this one, older unlinked entries are matched by amount
+	ListOrphanedTransfers(ctx context.Context, limit int32) ([]ListOrphanedTransfersRow, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error)
+	ListScheduledTransfersByOwner(ctx context.Context, arg ListScheduledTransfersByOwnerParams) ([]ScheduledTransfer, error)
+	ListTransferBatchItems(ctx context.Context, batchID int64) ([]TransferBatchItem, error)
+	ListTransferRequestEvents(ctx context.Context, requestID int64) ([]TransferRequestEvent, error)
+	// Requests made by the user or awaiting the approval of the user as co-signer
+	ListTransferRequests(ctx context.Context, arg ListTransferRequestsParams) ([]TransferRequest, error)
 	ListTransfersBetAccounts(ctx context.Context, arg ListTransfersBetAccountsParams) ([]Transfer, error)
+	ListTransfersByOwner(ctx context.Context, arg ListTransfersByOwnerParams) ([]Transfer, error)
 	ListTransfersFromAccount(ctx context.Context, arg ListTransfersFromAccountParams) ([]Transfer, error)
 	ListTransfersToAccount(ctx context.Context, arg ListTransfersToAccountParams) ([]Transfer, error)
+	// Posted transfers whose legs cannot balance: a leg that is not positive, or a
+	// transfer between accounts of one currency crediting another amount than it debits
+	ListUnbalancedTransfers(ctx context.Context, limit int32) ([]ListUnbalancedTransfersRow, error)
+	ReleaseAccountHold(ctx context.Context, arg ReleaseAccountHoldParams) (ReleaseAccountHoldRow, error)
+	SetAccountFrozen(ctx context.Context, arg SetAccountFrozenParams) (Account, error)
+	SetAccountLimit(ctx context.Context, arg SetAccountLimitParams) (Limit, error)
+	SetApprovalThreshold(ctx context.Context, arg SetApprovalThresholdParams) (Account, error)
+	SetOverdraftLimit(ctx context.Context, arg SetOverdraftLimitParams) (Account, error)
+	SetUserLimit(ctx context.Context, arg SetUserLimitParams) (Limit, error)
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error)
+	// Captures may post less than the held amount
+	SettleHold(ctx context.Context, arg SettleHoldParams) (Transfer, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) error
+	// Editing a schedule starts its failure count over
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountDrift(t *testing.T) {
+	store := NewStore(testDB)
+
+	// Funded through entries and transfers, nothing drifts
+	balanced := createTestAccountWithBalance(t, 0)
+	_, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID:         balanced.ID,
+		Amount:            100,
+		ExternalReference: "deposit-" + util.RandomString(12),
+	})
+	require.NoError(t, err)
+
+	// An opening balance without any entry drifts
+	drifting := createTestAccountWithBalance(t, 100)
+
+	drifts, err := testQueries.ListAccountDrift(context.Background(), math.MaxInt32)
+	require.NoError(t, err)
+
+	found := false
+	for _, drift := range drifts {
+		require.NotEqual(t, balanced.ID, drift.ID)
+		if drift.ID == drifting.ID {
+			found = true
+			require.Equal(t, int64(100), drift.Balance)
+			require.Zero(t, drift.EntriesTotal)
+			require.Zero(t, drift.TransfersNet)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestListOrphanedTransfers(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+
+	// A transfer row written without its entries
+	transferID, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+		ToAmount:      10,
+		ExchangeRate:  "1",
+	})
+	require.NoError(t, err)
+
+	orphaned, err := testQueries.ListOrphanedTransfers(context.Background(), math.MaxInt32)
+	require.NoError(t, err)
+
+	found := false
+	for _, row := range orphaned {
+		if row.ID == transferID {
+			found = true
+			require.False(t, row.HasDebit)
+			require.False(t, row.HasCredit)
+		}
+	}
+	require.True(t, found)
+}
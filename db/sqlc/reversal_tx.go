@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var (
+	// Returned when reversing a hold that is not posted or a transfer that is itself a reversal
+	ErrTransferNotReversible = errors.New("only posted transfers that are not reversals can be reversed")
+	// Returned when the whole amount of a transfer was already refunded
+	ErrTransferReversed = errors.New("transfer is already fully reversed")
+	// Returned when a refund exceeds the amount of the transfer not yet refunded
+	ErrReversalExceedsTransfer = errors.New("reversal amount exceeds the amount not yet reversed")
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// In the currency of the destination account of the transfer. Optional, refunds
+	// the whole amount not yet reversed when zero
+	Amount int64 `json:"amount"`
+	// Optional memo, defaults to naming the reversed transfer
+	Description string `json:"description"`
+	// Set when an admin makes the refund. Other refunds debit the recipient account like
+	// a transfer, within its approval threshold and limits
+	Admin bool `json:"-"`
+	// Optional, stores the result for replaying retries of the same request
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// Refunds a posted transfer with a compensating transfer from its destination back to
+// its source account, linked to it through reversal_of. Several partial refunds may be
+// made until the original amount is reached. Cross-currency transfers are refunded at
+// their original rate, the last refund returns exactly the rest of the original amount.
+// Unless an admin makes it, a refund above the approval threshold of the recipient
+// account fails with ErrApprovalRequired and a breached limit with *LimitExceededError
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		// Locking the original serializes concurrent refunds of the same transfer
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if original.Status != TransferPosted || original.ReversalOf.Valid {
+			return ErrTransferNotReversible
+		}
+
+		reversed, err := q.GetReversedAmount(ctx, sql.NullInt64{Int64: original.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		remaining := original.ToAmount - reversed.Amount
+		if remaining <= 0 {
+			return ErrTransferReversed
+		}
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return ErrReversalExceedsTransfer
+		}
+		if !arg.Admin {
+			recipient, err := q.GetAccount(ctx, original.ToAccountID)
+			if err != nil {
+				return err
+			}
+			if err = checkApprovalThreshold(recipient, amount); err != nil {
+				return err
+			}
+			if err = checkLimits(ctx, q, recipient, []int64{amount}, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		toAmount := original.Amount - reversed.ToAmount
+		if amount < remaining {
+			toAmount = proportion(amount, original.Amount, original.ToAmount)
+		}
+		rate, ok := new(big.Rat).SetString(original.ExchangeRate)
+		if !ok || rate.Sign() <= 0 {
+			return fmt.Errorf("invalid exchange rate %q of transfer %d", original.ExchangeRate, original.ID)
+		}
+
+		description := arg.Description
+		if description == "" {
+			description = fmt.Sprintf("Reversal of transfer %d", original.ID)
+		}
+
+		reversal, err := q.CreateReversal(ctx, CreateReversalParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        amount,
+			ToAmount:      toAmount,
+			ExchangeRate:  rate.Inv(rate).FloatString(8),
+			ReversalOf:    sql.NullInt64{Int64: original.ID, Valid: true},
+			Description:   description,
+		})
+		if err != nil {
+			return err
+		}
+		result.TransferID = reversal.ID
+		result.Amount = reversal.Amount
+		result.ToAmount = reversal.ToAmount
+		result.ExchangeRate = reversal.ExchangeRate
+		result.Description = reversal.Description
+
+		result.FromEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   reversal.FromAccountID,
+			Amount:      -reversal.Amount,
+			TransferID:  sql.NullInt64{Int64: reversal.ID, Valid: true},
+			Description: reversal.Description,
+		})
+		if err != nil {
+			return err
+		}
+		result.ToEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   reversal.ToAccountID,
+			Amount:      reversal.ToAmount,
+			TransferID:  sql.NullInt64{Int64: reversal.ID, Valid: true},
+			Description: reversal.Description,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Accounts are updated in ID order like in TransferTx to avoid deadlocks
+		if reversal.FromAccountID < reversal.ToAccountID {
+			result.FromAccountID, result.ToAccountID, result.FromBalance, result.ToBalance, err = moveMoney(ctx, q, reversal.FromAccountID, -reversal.Amount, reversal.ToAccountID, reversal.ToAmount)
+		} else {
+			result.ToAccountID, result.FromAccountID, result.ToBalance, result.FromBalance, err = moveMoney(ctx, q, reversal.ToAccountID, reversal.ToAmount, reversal.FromAccountID, -reversal.Amount)
+		}
+		if err != nil {
+			return err
+		}
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+
+	return result, err
+}
+
+// Returns amount * numerator / denominator rounded down, without overflowing int64
+func proportion(amount, numerator, denominator int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(amount), big.NewInt(numerator))
+	return product.Quo(product, big.NewInt(denominator)).Int64()
+}
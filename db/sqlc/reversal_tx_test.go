@@ -0,0 +1,154 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        60,
+	})
+	require.NoError(t, err)
+
+	// A partial refund followed by the rest
+	partial, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+		Amount:     25,
+	})
+	require.NoError(t, err)
+	require.Equal(t, acc2.ID, partial.FromAccountID)
+	require.Equal(t, acc1.ID, partial.ToAccountID)
+	require.Equal(t, int64(25), partial.Amount)
+	require.Equal(t, int64(65), partial.ToBalance)
+	require.Equal(t, int64(135), partial.FromBalance)
+
+	reversal, err := store.GetTransfer(context.Background(), partial.TransferID)
+	require.NoError(t, err)
+	require.Equal(t, sql.NullInt64{Int64: transfer.TransferID, Valid: true}, reversal.ReversalOf)
+	require.Equal(t, fmt.Sprintf("Reversal of transfer %d", transfer.TransferID), reversal.Description)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+		Amount:     40,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	rest, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(35), rest.Amount)
+	require.Equal(t, int64(100), rest.ToBalance)
+	require.Equal(t, int64(100), rest.FromBalance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+	})
+	require.ErrorIs(t, err, ErrTransferReversed)
+
+	// Reversals cannot be reversed themselves
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: partial.TransferID,
+	})
+	require.ErrorIs(t, err, ErrTransferNotReversible)
+}
+
+func TestReverseTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        100,
+		ToAmount:      92,
+		ExchangeRate:  "0.92",
+	})
+	require.NoError(t, err)
+
+	partial, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+		Amount:     46,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(50), partial.ToAmount)
+
+	// The last refund returns exactly what was left of the original amount
+	rest, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(46), rest.Amount)
+	require.Equal(t, int64(50), rest.ToAmount)
+	require.Equal(t, int64(1000), rest.ToBalance)
+}
+
+func TestReverseTransferTxPendingHold(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+	hold := createTestHold(t, store, acc1, acc2, 50, time.Now().Add(time.Hour))
+
+	_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: hold.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferNotReversible)
+}
+
+func TestReverseTransferTxApprovalThreshold(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountInCurrency(t, 1000, util.USD)
+	acc2 := createTestAccountWithThreshold(t, 0, 300)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        500,
+	})
+	require.NoError(t, err)
+
+	// A refund by the recipient debits their account within its threshold
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+	})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	partial, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+		Amount:     300,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(200), partial.FromBalance)
+
+	// An admin refund is not held to it
+	_, err = store.SetApprovalThreshold(context.Background(), SetApprovalThresholdParams{
+		ID:                acc2.ID,
+		ApprovalThreshold: sql.NullInt64{Int64: 100, Valid: true},
+	})
+	require.NoError(t, err)
+	rest, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.TransferID,
+		Admin:      true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(200), rest.Amount)
+	require.Zero(t, rest.FromBalance)
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTestScheduledTransfer(t *testing.T, nextRunAt time.Time) ScheduledTransfer {
+	from := createTestAccountWithBalance(t, 1000)
+	to := createTestAccountWithBalance(t, 0)
+
+	arg := CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Currency:      from.Currency,
+		Schedule:      "@daily",
+		NextRunAt:     nextRunAt,
+	}
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, scheduled.Owner)
+	require.Equal(t, arg.Schedule, scheduled.Schedule)
+	require.True(t, scheduled.Enabled)
+	require.Zero(t, scheduled.FailureCount)
+	require.False(t, scheduled.ClaimedUntil.Valid)
+
+	return scheduled
+}
+
+func containsScheduledTransfer(list []ScheduledTransfer, id int64) bool {
+	for _, scheduled := range list {
+		if scheduled.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClaimScheduledTransfersTx(t *testing.T) {
+	store := NewStore(testDB)
+	now := time.Now()
+	due := createTestScheduledTransfer(t, now.Add(-time.Minute))
+	later := createTestScheduledTransfer(t, now.Add(time.Hour))
+
+	arg := ClaimScheduledTransfersTxParams{Now: now, Limit: 1000, Lease: time.Minute}
+	claimed, err := store.ClaimScheduledTransfersTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, containsScheduledTransfer(claimed, due.ID))
+	require.False(t, containsScheduledTransfer(claimed, later.ID))
+
+	// A claimed row is skipped until its lease expires
+	claimed, err = store.ClaimScheduledTransfersTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, containsScheduledTransfer(claimed, due.ID))
+
+	arg.Now = now.Add(2 * time.Minute)
+	claimed, err = store.ClaimScheduledTransfersTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, containsScheduledTransfer(claimed, due.ID))
+}
+
+func TestFinishScheduledTransferRunTx(t *testing.T) {
+	store := NewStore(testDB)
+	scheduled := createTestScheduledTransfer(t, time.Now().Add(-time.Minute))
+	nextRunAt := time.Now().Add(24 * time.Hour)
+
+	result, err := store.FinishScheduledTransferRunTx(context.Background(), FinishScheduledTransferRunTxParams{
+		ScheduledTransfer: scheduled,
+		Error:             sql.NullString{String: ErrInsufficientFunds.Error(), Valid: true},
+		NextRunAt:         nextRunAt,
+		FailureCount:      1,
+		Enabled:           true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledRunFailed, result.Run.Status)
+	require.WithinDuration(t, scheduled.NextRunAt, result.Run.ScheduledFor, time.Second)
+	require.Equal(t, int32(1), result.ScheduledTransfer.FailureCount)
+	require.WithinDuration(t, nextRunAt, result.ScheduledTransfer.NextRunAt, time.Second)
+	require.True(t, result.ScheduledTransfer.LastRunAt.Valid)
+	require.False(t, result.ScheduledTransfer.ClaimedUntil.Valid)
+
+	runs, err := testQueries.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               10,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, result.Run.ID, runs[0].ID)
+
+	err = testQueries.DeleteScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	_, err = testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Outcomes of a scheduled transfer run
+const (
+	ScheduledRunSucceeded = "succeeded"
+	ScheduledRunFailed    = "failed"
+)
+
+type ClaimScheduledTransfersTxParams struct {
+	Now   time.Time
+	Limit int32
+	// How long other workers skip a claimed row, a run not finished by then is retried
+	Lease time.Duration
+}
+
+// Locks up to Limit due scheduled transfers, skipping rows locked by other workers, and
+// marks them claimed so they are not picked again while the caller executes them
+func (store *SQLStore) ClaimScheduledTransfersTx(ctx context.Context, arg ClaimScheduledTransfersTxParams) ([]ScheduledTransfer, error) {
+	var claimed []ScheduledTransfer
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		due, err := q.ListDueScheduledTransfers(ctx, ListDueScheduledTransfersParams{
+			Now:   arg.Now,
+			Limit: arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		claimedUntil := sql.NullTime{Time: arg.Now.Add(arg.Lease), Valid: true}
+		for i := range due {
+			err = q.ClaimScheduledTransfer(ctx, ClaimScheduledTransferParams{
+				ID:           due[i].ID,
+				ClaimedUntil: claimedUntil,
+			})
+			if err != nil {
+				return err
+			}
+			due[i].ClaimedUntil = claimedUntil
+		}
+		claimed = due
+		return nil
+	})
+
+	return claimed, err
+}
+
+type FinishScheduledTransferRunTxParams struct {
+	ScheduledTransfer ScheduledTransfer
+	// Set when the run created a transfer
+	TransferID sql.NullInt64
+	// Set when the run failed
+	Error        sql.NullString
+	NextRunAt    time.Time
+	FailureCount int32
+	Enabled      bool
+}
+
+type FinishScheduledTransferRunTxResult struct {
+	ScheduledTransfer ScheduledTransfer
+	Run               ScheduledTransferRun
+}
+
+// Records the outcome of a claimed run and releases the claim with the next run time
+func (store *SQLStore) FinishScheduledTransferRunTx(ctx context.Context, arg FinishScheduledTransferRunTxParams) (FinishScheduledTransferRunTxResult, error) {
+	var result FinishScheduledTransferRunTxResult
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		status := ScheduledRunSucceeded
+		if arg.Error.Valid {
+			status = ScheduledRunFailed
+		}
+
+		var err error
+		result.Run, err = q.CreateScheduledTransferRun(ctx, CreateScheduledTransferRunParams{
+			ScheduledTransferID: arg.ScheduledTransfer.ID,
+			ScheduledFor:        arg.ScheduledTransfer.NextRunAt,
+			Status:              status,
+			TransferID:          arg.TransferID,
+			Error:               arg.Error,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ScheduledTransfer, err = q.FinishScheduledTransferRun(ctx, FinishScheduledTransferRunParams{
+			ID:           arg.ScheduledTransfer.ID,
+			NextRunAt:    arg.NextRunAt,
+			FailureCount: arg.FailureCount,
+			Enabled:      arg.Enabled,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestSession(t *testing.T) Session {
+	user := createTestUser(t)
+
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestCreateSession(t *testing.T) {
+	createTestSession(t)
+}
+
+func TestGetSession(t *testing.T) {
+	session := createTestSession(t)
+
+	result, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, result.ID)
+	require.Equal(t, session.Username, result.Username)
+	require.Equal(t, session.RefreshToken, result.RefreshToken)
+	require.Equal(t, session.UserAgent, result.UserAgent)
+	require.Equal(t, session.ClientIp, result.ClientIp)
+}
+
+func TestBlockSession(t *testing.T) {
+	session := createTestSession(t)
+
+	rows, err := testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: util.RandomOwner(),
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: session.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	blocked, err := testQueries.GetSessionBlocked(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	session := createTestSession(t)
+
+	ids, err := testQueries.BlockUserSessions(context.Background(), session.Username)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{session.ID}, ids)
+
+	// Already blocked sessions are not returned again
+	ids, err = testQueries.BlockUserSessions(context.Background(), session.Username)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
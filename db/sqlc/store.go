@@ -3,108 +3,262 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// Returned when a debit would take an account below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// Returned when the FX quote of a transfer expired or was consumed by another transfer
+var ErrQuoteUnavailable = errors.New("fx quote is expired or already used")
+
+// Returned when a debit is above the approval threshold of its account. Such a transfer
+// is only posted by approving a transfer request
+var ErrApprovalRequired = errors.New("amount is above the approval threshold of account")
+
 // Provides functions to execute all Quesris and Transactions
 type Store interface {
 	Querier // Inherit all quering functions generated by SQLC
 	TransferTx(ctx context.Context, arg TransferTxParms) (TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	PostTx(ctx context.Context, arg PostTxParams) (PostTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error)
+	HoldTx(ctx context.Context, arg HoldTxParams) (HoldTxResult, error)
+	CaptureTx(ctx context.Context, arg CaptureTxParams) (TransferTxResult, error)
+	VoidTx(ctx context.Context, transferID int64) (Transfer, error)
+	ExpireHoldsTx(ctx context.Context, arg ExpireHoldsTxParams) ([]Transfer, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	DepositTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error)
+	CreateTransferRequestTx(ctx context.Context, arg CreateTransferRequestTxParams) (TransferRequest, error)
+	ApproveTransferRequestTx(ctx context.Context, arg ApproveTransferRequestTxParams) (ApproveTransferRequestTxResult, error)
+	RejectTransferRequestTx(ctx context.Context, arg RejectTransferRequestTxParams) (TransferRequest, error)
+	ExpireTransferRequestsTx(ctx context.Context, arg ExpireTransferRequestsTxParams) ([]TransferRequest, error)
+	ClaimScheduledTransfersTx(ctx context.Context, arg ClaimScheduledTransfersTxParams) ([]ScheduledTransfer, error)
+	FinishScheduledTransferRunTx(ctx context.Context, arg FinishScheduledTransferRunTxParams) (FinishScheduledTransferRunTxResult, error)
+	TxStats() TxStats
 }
 
 // Provides functions to execute all Queries and Transations on a SQL database
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	db       *sql.DB
+	config   TxConfig
+	counters txCounters
 }
 
-// Creates a new store instance
+// Creates a new store instance retrying transactions with the default TxConfig
 func NewStore(db *sql.DB) Store {
-	return &SQLStore{
-		db:      db,
-		Queries: New(db),
-	}
+	return NewStoreWithConfig(db, TxConfig{})
 }
 
-//  Executes a function within a databasr transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
+// Creates a new store instance retrying transactions as configured
+func NewStoreWithConfig(db *sql.DB, config TxConfig) Store {
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = defaultMaxTxRetries
 	}
-
-	q := New(tx)
-	err = fn(q)
-	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("TxErr: %v and RbErr: %v", err, rbErr)
-		}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultTxRetryDelay
+	}
+	return &SQLStore{
+		db:      db,
+		Queries: New(db),
+		config:  config,
 	}
-	return tx.Commit()
 }
 
+// Moves Amount out of the source account and ToAmount into the destination account.
+// Same currency transfers may leave ToAmount and ExchangeRate empty to credit Amount at rate 1.
+// A transfer with a QuoteID takes both from the quote, which it consumes
 type TransferTxParms struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	ToAmount      int64         `json:"to_amount"`
+	ExchangeRate  string        `json:"exchange_rate"`
+	QuoteID       uuid.NullUUID `json:"quote_id"`
+	// Set by deposits and withdrawals, unique across transfers
+	ExternalReference sql.NullString `json:"external_reference"`
+	// Optional memo stored on the transfer and both entries
+	Description string `json:"description"`
+	// Optional, stores the result for replaying retries of the same request
+	Idempotency *IdempotencyParams `json:"-"`
 }
 
 type TransferTxResult struct {
-	TransferID    int64 `json:"transfer_id"`
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	FromEntryID   int64 `json:"from_entry_id"`
-	ToEntryID     int64 `json:"to_entry_id"`
-	FromBalance   int64 `json:"from_balance"`
-	ToBalance     int64 `json:"to_balance"`
+	TransferID    int64  `json:"transfer_id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	FromEntryID   int64  `json:"from_entry_id"`
+	ToEntryID     int64  `json:"to_entry_id"`
+	FromBalance   int64  `json:"from_balance"`
+	ToBalance     int64  `json:"to_balance"`
+	Amount        int64  `json:"amount"`
+	ToAmount      int64  `json:"to_amount"`
+	ExchangeRate  string `json:"exchange_rate"`
+	Description   string `json:"description"`
 }
 
+// Posts a transfer once it passes the limits of its source account and of the owner of
+// that account, a breached limit is reported as a *LimitExceededError. A transfer above
+// the approval threshold of the source account fails with ErrApprovalRequired
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParms) (TransferTxResult, error) {
 
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
-		result.TransferID, err = q.CreateTransfer(ctx, CreateTransferParams(arg))
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		from, err := q.GetAccount(ctx, arg.FromAccountID)
 		if err != nil {
 			return err
 		}
-
-		result.FromEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
+		if err = checkApprovalThreshold(from, arg.Amount); err != nil {
 			return err
 		}
+		result, err = limitedTransfer(ctx, q, from, arg, time.Now())
+		return err
+	})
+
+	return result, err
+}
+
+// Checks debits of amounts out of the account against its approval threshold
+func checkApprovalThreshold(account Account, amounts ...int64) error {
+	threshold := account.ApprovalThreshold
+	if !threshold.Valid {
+		return nil
+	}
+	for _, amount := range amounts {
+		if amount > threshold.Int64 {
+			return fmt.Errorf("%w %d", ErrApprovalRequired, account.ID)
+		}
+	}
+	return nil
+}
+
+// Checks the limits of the source account at now, then posts the transfer within the
+// transaction of q
+func limitedTransfer(ctx context.Context, q *Queries, from Account, arg TransferTxParms, now time.Time) (TransferTxResult, error) {
+	if err := checkLimits(ctx, q, from, []int64{arg.Amount}, now); err != nil {
+		return TransferTxResult{}, err
+	}
+	return transfer(ctx, q, arg)
+}
+
+// Posts a transfer and its entries within the transaction of q
+func transfer(ctx context.Context, q *Queries, arg TransferTxParms) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	if arg.QuoteID.Valid {
+		if err = applyQuote(ctx, q, &arg); err != nil {
+			return result, err
+		}
+	}
+	if arg.ToAmount == 0 {
+		arg.ToAmount = arg.Amount
+	}
+	if arg.ExchangeRate == "" {
+		arg.ExchangeRate = "1"
+	}
+	result.Amount = arg.Amount
+	result.ToAmount = arg.ToAmount
+	result.ExchangeRate = arg.ExchangeRate
+	result.Description = arg.Description
 
-		result.ToEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+	result.TransferID, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:     arg.FromAccountID,
+		ToAccountID:       arg.ToAccountID,
+		Amount:            arg.Amount,
+		ToAmount:          arg.ToAmount,
+		ExchangeRate:      arg.ExchangeRate,
+		ExternalReference: arg.ExternalReference,
+		Description:       arg.Description,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.FromEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.FromAccountID,
+		Amount:      -arg.Amount,
+		TransferID:  sql.NullInt64{Int64: result.TransferID, Valid: true},
+		Description: arg.Description,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntryID, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.ToAccountID,
+		Amount:      arg.ToAmount,
+		TransferID:  sql.NullInt64{Int64: result.TransferID, Valid: true},
+		Description: arg.Description,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// update account balances
+	// To avoid deadlock between concurrent transactions always
+	// add or remove from the account with the smallest ID first
+
+	if (arg.FromAccountID < arg.ToAccountID){
+		result.FromAccountID, result.ToAccountID, result.FromBalance, result.ToBalance, err = moveMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.ToAmount)
 		if err != nil {
-			return err
+			return result, err
+		}
+	} else {
+		result.ToAccountID, result.FromAccountID, result.ToBalance, result.FromBalance, err = moveMoney(ctx, q, arg.ToAccountID, arg.ToAmount, arg.FromAccountID, -arg.Amount)
+		if err != nil {
+			return result, err
 		}
+	}
+	return result, saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+}
 
-		// update account balances
-		// To avoid deadlock between concurrent transactions always
-		// add or remove from the account with the smallest ID first
-
-		if (arg.FromAccountID < arg.ToAccountID){
-			result.FromAccountID, result.ToAccountID, result.FromBalance, result.ToBalance, err = moveMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-			if err != nil {
-				return err
-			}
-		} else {
-			result.ToAccountID, result.FromAccountID, result.ToBalance, result.FromBalance, err = moveMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-			if err != nil {
-				return err
-			}
+// Consumes the quote of the transfer and takes the converted amount and rate from it
+func applyQuote(ctx context.Context, q *Queries, arg *TransferTxParms) error {
+	quote, err := q.ConsumeFxQuote(ctx, arg.QuoteID.UUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrQuoteUnavailable
 		}
-		return nil
+		return err
+	}
+	if quote.Amount != arg.Amount {
+		return fmt.Errorf("fx quote %s is for amount %d, not %d", quote.ID, quote.Amount, arg.Amount)
+	}
+	arg.ToAmount = quote.ToAmount
+	arg.ExchangeRate = quote.ExchangeRate
+	return nil
+}
+
+type CreateAccountTxParams struct {
+	CreateAccountParams
+	// Optional, stores the account for replaying retries of the same request
+	Idempotency *IdempotencyParams
+}
+
+// Creates an account and records the idempotency key of the request in one transaction
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+		account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, account)
 	})
 
-	return result, err
+	return account, err
 }
 
 func moveMoney(
@@ -115,17 +269,11 @@ func moveMoney(
 	accountID2 int64,
 	amt2 int64,
 ) (accID1, accID2, bal1, bal2 int64, err error) {
-	account1, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
-			ID: accountID1,
-			Amount: amt1,
-	})
+	account1, err := updateAccountBalance(ctx, q, accountID1, amt1)
 	if err != nil {
 		return
 	}
-	account2, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID: accountID2,
-		Amount: amt2,
-	})
+	account2, err := updateAccountBalance(ctx, q, accountID2, amt2)
 	if err != nil {
 		return
 	}
@@ -135,3 +283,20 @@ func moveMoney(
 	bal2 = account2.Balance
 	return
 }
+
+// Adds the amount to the account balance. AddAccountBalance matches no row when a
+// debit would exceed the overdraft limit, which is reported as ErrInsufficientFunds,
+// or when the account does not exist, which is reported as sql.ErrNoRows
+func updateAccountBalance(ctx context.Context, q *Queries, accountID int64, amount int64) (AddAccountBalanceRow, error) {
+	account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     accountID,
+		Amount: amount,
+	})
+	if err == sql.ErrNoRows && amount < 0 {
+		if _, err = q.GetAccount(ctx, accountID); err != nil {
+			return account, err
+		}
+		return account, ErrInsufficientFunds
+	}
+	return account, err
+}
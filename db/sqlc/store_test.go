@@ -1,17 +1,23 @@
 package db
 
 import (
+	"bank/util"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	acc1 := createTestAccount(t)
-	acc2 := createTestAccount(t)
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
 
 	// run concurrent routines to test the transaction
 	n := 5
@@ -25,6 +31,7 @@ func TestTransferTx(t *testing.T) {
 				FromAccountID: acc1.ID,
 				ToAccountID:   acc2.ID,
 				Amount:        amount,
+				Description:   "Shared dinner",
 			})
 			errs <- err
 			results <- result
@@ -47,6 +54,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, acc1.ID, transfer.FromAccountID)
 		require.Equal(t, acc2.ID, transfer.ToAccountID)
 		require.Equal(t, amount, transfer.Amount)
+		require.Equal(t, "Shared dinner", transfer.Description)
 
 		entryFrom, err := store.GetEntry(context.Background(), GetEntryParams{
 			AccountID: acc1.ID,
@@ -56,6 +64,8 @@ func TestTransferTx(t *testing.T) {
 		require.NotZero(t, entryFrom.ID)
 		require.NotZero(t, entryFrom.CreatedAt)
 		require.Equal(t, -amount, entryFrom.Amount)
+		require.Equal(t, sql.NullInt64{Int64: transfer.ID, Valid: true}, entryFrom.TransferID)
+		require.Equal(t, transfer.Description, entryFrom.Description)
 
 		entryTo, err := store.GetEntry(context.Background(), GetEntryParams{
 			AccountID: acc2.ID,
@@ -65,6 +75,8 @@ func TestTransferTx(t *testing.T) {
 		require.NotZero(t, entryFrom.ID)
 		require.NotZero(t, entryFrom.CreatedAt)
 		require.Equal(t, amount, entryTo.Amount)
+		require.Equal(t, sql.NullInt64{Int64: transfer.ID, Valid: true}, entryTo.TransferID)
+		require.Equal(t, transfer.Description, entryTo.Description)
 
 		// TODO: Check account balnace
 		diff1 := acc1.Balance - result.FromBalance
@@ -94,8 +106,8 @@ func TestTransferTx(t *testing.T) {
 func TestDeadlockTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	acc1 := createTestAccount(t)
-	acc2 := createTestAccount(t)
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
 
 	// run concurrent routines to test the transaction
 	n := 10
@@ -138,3 +150,164 @@ func TestDeadlockTransferTx(t *testing.T) {
 	require.Equal(t, acc1.Balance, updatedAcc1.Balance)
 	require.Equal(t, acc2.Balance, updatedAcc2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        101,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// Nothing is written when the debit fails
+	result1, err := testQueries.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance, result1.Balance)
+	result2, err := testQueries.GetAccount(context.Background(), acc2.ID)
+	require.NoError(t, err)
+	require.Equal(t, acc2.Balance, result2.Balance)
+}
+
+func TestUpdateAccountBalanceMissingAccount(t *testing.T) {
+	account := createTestAccountWithBalance(t, 100)
+
+	// A debit beyond the overdraft limit of an existing account
+	_, err := updateAccountBalance(context.Background(), testQueries, account.ID, -101)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// A debit of an account that does not exist is not reported as insufficient funds
+	_, err = updateAccountBalance(context.Background(), testQueries, -account.ID, -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferTxOverdraftLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+
+	_, err := testQueries.SetOverdraftLimit(context.Background(), SetOverdraftLimitParams{
+		ID:             acc1.ID,
+		OverdraftLimit: 50,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        150,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-50), result.FromBalance)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestTransferTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 100)
+	acc2 := createTestAccountWithBalance(t, 100)
+
+	arg := TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+		Idempotency: &IdempotencyParams{
+			Key:         util.RandomString(16),
+			Username:    acc1.Owner,
+			RequestHash: util.RandomString(64),
+		},
+	}
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	stored, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.Idempotency.RequestHash, stored.RequestHash)
+	require.Equal(t, int32(http.StatusOK), stored.Status)
+
+	var storedResult TransferTxResult
+	require.NoError(t, json.Unmarshal(stored.Response, &storedResult))
+	require.Equal(t, result, storedResult)
+
+	// Executing the same key again rolls back the second transfer
+	_, err = store.TransferTx(context.Background(), arg)
+	require.Error(t, err)
+
+	updatedAcc1, err := testQueries.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.FromBalance, updatedAcc1.Balance)
+}
+
+func TestTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        100,
+		ToAmount:      92,
+		ExchangeRate:  "0.92",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(900), result.FromBalance)
+	require.Equal(t, int64(1092), result.ToBalance)
+
+	transfer, err := testQueries.GetTransfer(context.Background(), result.TransferID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), transfer.Amount)
+	require.Equal(t, int64(92), transfer.ToAmount)
+	require.Equal(t, "0.92000000", transfer.ExchangeRate)
+
+	toEntry, err := testQueries.GetEntry(context.Background(), GetEntryParams{
+		ID:        result.ToEntryID,
+		AccountID: acc2.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(92), toEntry.Amount)
+}
+
+func TestTransferTxWithQuote(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createTestAccountWithBalance(t, 1000)
+	acc2 := createTestAccountWithBalance(t, 1000)
+	quote := createTestFxQuote(t, acc1.Owner, time.Now().Add(time.Minute))
+
+	arg := TransferTxParms{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        quote.Amount,
+		QuoteID:       uuid.NullUUID{UUID: quote.ID, Valid: true},
+	}
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, quote.ToAmount, result.ToAmount)
+	require.Equal(t, quote.ExchangeRate, result.ExchangeRate)
+	require.Equal(t, int64(1092), result.ToBalance)
+
+	// The quote cannot back a second transfer
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteUnavailable)
+
+	account, err := testQueries.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(900), account.Balance)
+}
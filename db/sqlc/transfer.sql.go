@@ -7,33 +7,99 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const createHold = `-- name: CreateHold :one
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  status,
+  expires_at,
+  description
+) VALUES (
+  $1, $2, $3, $3, 'pending', $4, $5
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description
+`
+
+type CreateHoldParams struct {
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        int64        `json:"amount"`
+	ExpiresAt     sql.NullTime `json:"expires_at"`
+	Description   string       `json:"description"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createHold,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ExpiresAt,
+		arg.Description,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReversalOf,
+		&i.ExternalReference,
+		&i.Description,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate,
+  external_reference,
+  description
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6, $7
 ) RETURNING id
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID     int64          `json:"from_account_id"`
+	ToAccountID       int64          `json:"to_account_id"`
+	Amount            int64          `json:"amount"`
+	ToAmount          int64          `json:"to_amount"`
+	ExchangeRate      string         `json:"exchange_rate"`
+	ExternalReference sql.NullString `json:"external_reference"`
+	Description       string         `json:"description"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ExternalReference,
+		arg.Description,
+	)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -46,12 +112,172 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReversalOf,
+		&i.ExternalReference,
+		&i.Description,
 	)
 	return i, err
 }
 
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReversalOf,
+		&i.ExternalReference,
+		&i.Description,
+	)
+	return i, err
+}
+
+const listAccountTransfers = `-- name: ListAccountTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description FROM transfers
+WHERE (
+  ($1::text IN ('out', 'both') AND from_account_id = $2)
+  OR ($1::text IN ('in', 'both') AND to_account_id = $2)
+)
+AND ($3::bigint IS NULL OR from_account_id = $3 OR to_account_id = $3)
+AND ($4::timestamptz IS NULL OR created_at >= $4)
+AND ($5::timestamptz IS NULL OR created_at < $5)
+AND ($6::bigint IS NULL OR amount >= $6)
+AND ($7::bigint IS NULL OR amount <= $7)
+AND ($8::text IS NULL OR status = $8)
+AND ($9::bigint IS NULL OR id < $9)
+ORDER BY id DESC
+LIMIT $10
+`
+
+type ListAccountTransfersParams struct {
+	Direction      string         `json:"direction"`
+	AccountID      int64          `json:"account_id"`
+	CounterpartyID sql.NullInt64  `json:"counterparty_id"`
+	CreatedFrom    sql.NullTime   `json:"created_from"`
+	CreatedTo      sql.NullTime   `json:"created_to"`
+	MinAmount      sql.NullInt64  `json:"min_amount"`
+	MaxAmount      sql.NullInt64  `json:"max_amount"`
+	Status         sql.NullString `json:"status"`
+	BeforeID       sql.NullInt64  `json:"before_id"`
+	Limit          int32          `json:"limit"`
+}
+
+func (q *Queries) ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountTransfers,
+		arg.Direction,
+		arg.AccountID,
+		arg.CounterpartyID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Status,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ReversalOf,
+			&i.ExternalReference,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredHolds = `-- name: ListExpiredHolds :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description FROM transfers
+WHERE status = 'pending'
+AND expires_at <= $1::timestamptz
+ORDER BY from_account_id, id
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type ListExpiredHoldsParams struct {
+	Now   time.Time `json:"now"`
+	Limit int32     `json:"limit"`
+}
+
+func (q *Queries) ListExpiredHolds(ctx context.Context, arg ListExpiredHoldsParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredHolds, arg.Now, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ReversalOf,
+			&i.ExternalReference,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfersBetAccounts = `-- name: ListTransfersBetAccounts :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description FROM transfers
 WHERE to_account_id = $1
 AND from_account_id = $2
 ORDER BY id
@@ -86,6 +312,66 @@ func (q *Queries) ListTransfersBetAccounts(ctx context.Context, arg ListTransfer
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ReversalOf,
+			&i.ExternalReference,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersByOwner = `-- name: ListTransfersByOwner :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.to_amount, t.exchange_rate, t.status, t.expires_at, t.reversal_of, t.external_reference, t.description FROM transfers t
+WHERE (
+  t.from_account_id IN (SELECT id FROM accounts WHERE owner = $1)
+  OR t.to_account_id IN (SELECT id FROM accounts WHERE owner = $1)
+)
+AND ($2::bigint IS NULL OR t.id < $2)
+ORDER BY t.id DESC
+LIMIT $3
+`
+
+type ListTransfersByOwnerParams struct {
+	Owner    string        `json:"owner"`
+	BeforeID sql.NullInt64 `json:"before_id"`
+	Limit    int32         `json:"limit"`
+}
+
+func (q *Queries) ListTransfersByOwner(ctx context.Context, arg ListTransfersByOwnerParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersByOwner, arg.Owner, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ReversalOf,
+			&i.ExternalReference,
+			&i.Description,
 		); err != nil {
 			return nil, err
 		}
@@ -101,7 +387,7 @@ func (q *Queries) ListTransfersBetAccounts(ctx context.Context, arg ListTransfer
 }
 
 const listTransfersFromAccount = `-- name: ListTransfersFromAccount :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description FROM transfers
 WHERE from_account_id = $1
 ORDER BY id
 LIMIT $2
@@ -129,6 +415,13 @@ func (q *Queries) ListTransfersFromAccount(ctx context.Context, arg ListTransfer
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ReversalOf,
+			&i.ExternalReference,
+			&i.Description,
 		); err != nil {
 			return nil, err
 		}
@@ -144,7 +437,7 @@ func (q *Queries) ListTransfersFromAccount(ctx context.Context, arg ListTransfer
 }
 
 const listTransfersToAccount = `-- name: ListTransfersToAccount :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description FROM transfers
 WHERE to_account_id = $1
 ORDER BY id
 LIMIT $2
@@ -172,6 +465,13 @@ func (q *Queries) ListTransfersToAccount(ctx context.Context, arg ListTransfersT
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ReversalOf,
+			&i.ExternalReference,
+			&i.Description,
 		); err != nil {
 			return nil, err
 		}
@@ -185,3 +485,113 @@ func (q *Queries) ListTransfersToAccount(ctx context.Context, arg ListTransfersT
 	}
 	return items, nil
 }
+
+const settleHold = `-- name: SettleHold :one
+UPDATE transfers
+set status = $2,
+  amount = $3,
+  to_amount = $3
+WHERE id = $1
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description
+`
+
+type SettleHoldParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	Amount int64  `json:"amount"`
+}
+
+// Captures may post less than the held amount
+func (q *Queries) SettleHold(ctx context.Context, arg SettleHoldParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, settleHold, arg.ID, arg.Status, arg.Amount)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReversalOf,
+		&i.ExternalReference,
+		&i.Description,
+	)
+	return i, err
+}
+
+const createReversal = `-- name: CreateReversal :one
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  exchange_rate,
+  reversal_of,
+  description
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, expires_at, reversal_of, external_reference, description
+`
+
+type CreateReversalParams struct {
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	ToAmount      int64         `json:"to_amount"`
+	ExchangeRate  string        `json:"exchange_rate"`
+	ReversalOf    sql.NullInt64 `json:"reversal_of"`
+	Description   string        `json:"description"`
+}
+
+func (q *Queries) CreateReversal(ctx context.Context, arg CreateReversalParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createReversal,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ReversalOf,
+		arg.Description,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReversalOf,
+		&i.ExternalReference,
+		&i.Description,
+	)
+	return i, err
+}
+
+const getReversedAmount = `-- name: GetReversedAmount :one
+SELECT
+  COALESCE(SUM(amount), 0)::bigint AS amount,
+  COALESCE(SUM(to_amount), 0)::bigint AS to_amount
+FROM transfers
+WHERE reversal_of = $1
+`
+
+type GetReversedAmountRow struct {
+	Amount   int64 `json:"amount"`
+	ToAmount int64 `json:"to_amount"`
+}
+
+// Sums of the refunds of a transfer, amount is in the currency of its destination
+// account and to_amount in the currency of its source account
+func (q *Queries) GetReversedAmount(ctx context.Context, reversalOf sql.NullInt64) (GetReversedAmountRow, error) {
+	row := q.db.QueryRowContext(ctx, getReversedAmount, reversalOf)
+	var i GetReversedAmountRow
+	err := row.Scan(&i.Amount, &i.ToAmount)
+	return i, err
+}
@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Statuses of a transfer request. Requests wait as pending until a co-signer approves
+// or rejects them, or until they expire
+const (
+	TransferRequestPending  = "pending"
+	TransferRequestApproved = "approved"
+	TransferRequestRejected = "rejected"
+	TransferRequestExpired  = "expired"
+)
+
+// Audit trail action recorded when a request is made, later actions are named after
+// the status the request moves to
+const TransferRequestRequested = "requested"
+
+var (
+	// Returned when approving or rejecting a request that was already decided or expired
+	ErrTransferRequestNotPending = errors.New("transfer request is not pending")
+	// Returned when approving a request after it expired
+	ErrTransferRequestExpired = errors.New("transfer request has expired")
+	// Returned when the user who made a request tries to approve it
+	ErrSelfApproval = errors.New("transfer request must be approved by another user than the requester")
+)
+
+type CreateTransferRequestTxParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Description   string    `json:"description"`
+	RequestedBy   string    `json:"requested_by"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	// Optional, stores the result for replaying retries of the same request
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// Records a transfer waiting for the approval of a co-signer instead of posting it
+func (store *SQLStore) CreateTransferRequestTx(ctx context.Context, arg CreateTransferRequestTxParams) (TransferRequest, error) {
+	var request TransferRequest
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+		request, err = q.CreateTransferRequest(ctx, CreateTransferRequestParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			Description:   arg.Description,
+			RequestedBy:   arg.RequestedBy,
+			ExpiresAt:     arg.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		err = recordTransferRequestEvent(ctx, q, request.ID, TransferRequestRequested, arg.RequestedBy, "")
+		if err != nil {
+			return err
+		}
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, request)
+	})
+
+	return request, err
+}
+
+type ApproveTransferRequestTxParams struct {
+	RequestID int64
+	Approver  string
+	// Set for requests between accounts of different currencies, converted at approval
+	ToAmount     int64
+	ExchangeRate string
+	Now          time.Time
+}
+
+type ApproveTransferRequestTxResult struct {
+	Request  TransferRequest  `json:"request"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// Posts the transfer of a pending request as TransferTx does, limits included but not
+// the approval threshold, and records who approved it. The request stays pending when
+// the transfer fails
+func (store *SQLStore) ApproveTransferRequestTx(ctx context.Context, arg ApproveTransferRequestTxParams) (ApproveTransferRequestTxResult, error) {
+	var result ApproveTransferRequestTxResult
+
+	err := store.execTx(ctx, serializable, func(q *Queries) error {
+		request, err := pendingTransferRequest(ctx, q, arg.RequestID)
+		if err != nil {
+			return err
+		}
+		if !arg.Now.Before(request.ExpiresAt) {
+			return ErrTransferRequestExpired
+		}
+		if request.RequestedBy == arg.Approver {
+			return ErrSelfApproval
+		}
+
+		from, err := q.GetAccount(ctx, request.FromAccountID)
+		if err != nil {
+			return err
+		}
+		result.Transfer, err = limitedTransfer(ctx, q, from, TransferTxParms{
+			FromAccountID: request.FromAccountID,
+			ToAccountID:   request.ToAccountID,
+			Amount:        request.Amount,
+			ToAmount:      arg.ToAmount,
+			ExchangeRate:  arg.ExchangeRate,
+			Description:   request.Description,
+		}, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		result.Request, err = q.DecideTransferRequest(ctx, DecideTransferRequestParams{
+			ID:         request.ID,
+			Status:     TransferRequestApproved,
+			DecidedBy:  sql.NullString{String: arg.Approver, Valid: true},
+			TransferID: sql.NullInt64{Int64: result.Transfer.TransferID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return recordTransferRequestEvent(ctx, q, request.ID, TransferRequestApproved, arg.Approver, "")
+	})
+
+	return result, err
+}
+
+type RejectTransferRequestTxParams struct {
+	RequestID int64
+	Rejecter  string
+	// Optional, kept in the audit trail
+	Reason string
+}
+
+// Declines a pending request, nothing is posted
+func (store *SQLStore) RejectTransferRequestTx(ctx context.Context, arg RejectTransferRequestTxParams) (TransferRequest, error) {
+	var request TransferRequest
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		pending, err := pendingTransferRequest(ctx, q, arg.RequestID)
+		if err != nil {
+			return err
+		}
+		request, err = q.DecideTransferRequest(ctx, DecideTransferRequestParams{
+			ID:        pending.ID,
+			Status:    TransferRequestRejected,
+			DecidedBy: sql.NullString{String: arg.Rejecter, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return recordTransferRequestEvent(ctx, q, request.ID, TransferRequestRejected, arg.Rejecter, arg.Reason)
+	})
+
+	return request, err
+}
+
+type ExpireTransferRequestsTxParams struct {
+	Now   time.Time
+	Limit int32
+}
+
+// Expires up to Limit pending requests that were not decided before Now, skipping
+// requests that are being approved or rejected
+func (store *SQLStore) ExpireTransferRequestsTx(ctx context.Context, arg ExpireTransferRequestsTxParams) ([]TransferRequest, error) {
+	var expired []TransferRequest
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		requests, err := q.ListExpiredTransferRequests(ctx, ListExpiredTransferRequestsParams{
+			Now:   arg.Now,
+			Limit: arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		expired = make([]TransferRequest, 0, len(requests))
+		for _, request := range requests {
+			request, err = q.DecideTransferRequest(ctx, DecideTransferRequestParams{
+				ID:     request.ID,
+				Status: TransferRequestExpired,
+			})
+			if err != nil {
+				return err
+			}
+			if err = recordTransferRequestEvent(ctx, q, request.ID, TransferRequestExpired, "", ""); err != nil {
+				return err
+			}
+			expired = append(expired, request)
+		}
+		return nil
+	})
+
+	return expired, err
+}
+
+// Locks the request and checks that it is pending
+func pendingTransferRequest(ctx context.Context, q *Queries, requestID int64) (TransferRequest, error) {
+	request, err := q.GetTransferRequestForUpdate(ctx, requestID)
+	if err != nil {
+		return TransferRequest{}, err
+	}
+	if request.Status != TransferRequestPending {
+		return TransferRequest{}, ErrTransferRequestNotPending
+	}
+	return request, nil
+}
+
+// Appends to the audit trail of a request, an empty actor stands for the system
+func recordTransferRequestEvent(ctx context.Context, q *Queries, requestID int64, action string, actor string, note string) error {
+	_, err := q.CreateTransferRequestEvent(ctx, CreateTransferRequestEventParams{
+		RequestID: requestID,
+		Action:    action,
+		Actor:     sql.NullString{String: actor, Valid: actor != ""},
+		Note:      note,
+	})
+	return err
+}
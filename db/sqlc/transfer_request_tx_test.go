@@ -0,0 +1,268 @@
+package db
+
+import (
+	"bank/util"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTestTransferRequest(t *testing.T, store Store, from, to Account, amount int64, expiresAt time.Time) TransferRequest {
+	request, err := store.CreateTransferRequestTx(context.Background(), CreateTransferRequestTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Description:   "Supplier invoice",
+		RequestedBy:   from.Owner,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestPending, request.Status)
+	return request
+}
+
+func TestApproveTransferRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountInCurrency(t, 1000, util.USD)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	checker := createTestUser(t)
+	request := createTestTransferRequest(t, store, from, to, 400, time.Now().Add(time.Hour))
+
+	// The requester cannot approve their own request
+	_, err := store.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		RequestID: request.ID,
+		Approver:  from.Owner,
+		Now:       time.Now(),
+	})
+	require.ErrorIs(t, err, ErrSelfApproval)
+
+	result, err := store.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		RequestID: request.ID,
+		Approver:  checker.Username,
+		Now:       time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestApproved, result.Request.Status)
+	require.Equal(t, checker.Username, result.Request.DecidedBy.String)
+	require.Equal(t, result.Transfer.TransferID, result.Request.TransferID.Int64)
+	require.Equal(t, int64(600), result.Transfer.FromBalance)
+
+	// A decided request cannot be approved twice
+	_, err = store.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		RequestID: request.ID,
+		Approver:  checker.Username,
+		Now:       time.Now(),
+	})
+	require.ErrorIs(t, err, ErrTransferRequestNotPending)
+
+	events, err := store.ListTransferRequestEvents(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, TransferRequestRequested, events[0].Action)
+	require.Equal(t, TransferRequestApproved, events[1].Action)
+	require.Equal(t, checker.Username, events[1].Actor.String)
+}
+
+func TestApproveTransferRequestTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountInCurrency(t, 100, util.USD)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	checker := createTestUser(t)
+	request := createTestTransferRequest(t, store, from, to, 400, time.Now().Add(time.Hour))
+
+	_, err := store.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		RequestID: request.ID,
+		Approver:  checker.Username,
+		Now:       time.Now(),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// The failed approval was rolled back, the request can still be decided
+	pending, err := store.GetTransferRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestPending, pending.Status)
+}
+
+func createTestAccountWithThreshold(t *testing.T, balance int64, threshold int64) Account {
+	account := createTestAccountInCurrency(t, balance, util.USD)
+	account, err := testQueries.SetApprovalThreshold(context.Background(), SetApprovalThresholdParams{
+		ID:                account.ID,
+		ApprovalThreshold: sql.NullInt64{Int64: threshold, Valid: true},
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestTransferTxApprovalThreshold(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountWithThreshold(t, 1000, 300)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	checker := createTestUser(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParms{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 301})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+	_, err = store.TransferTx(context.Background(), TransferTxParms{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 300})
+	require.NoError(t, err)
+
+	// Approving a request is how a transfer above the threshold gets posted
+	request := createTestTransferRequest(t, store, from, to, 400, time.Now().Add(time.Hour))
+	result, err := store.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		RequestID: request.ID,
+		Approver:  checker.Username,
+		Now:       time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(300), result.Transfer.FromBalance)
+}
+
+func TestBatchTransferTxApprovalThreshold(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountWithThreshold(t, 1000, 300)
+	to1 := createTestAccountInCurrency(t, 0, util.USD)
+	to2 := createTestAccountInCurrency(t, 0, util.USD)
+	arg := BatchTransferTxParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		Mode:          BatchAllOrNothing,
+		Items: []BatchTransferItem{
+			{ToAccountID: to1.ID, Amount: 200},
+			{ToAccountID: to2.ID, Amount: 400},
+		},
+	}
+
+	var itemErr *BatchItemError
+	_, err := store.BatchTransferTx(context.Background(), arg)
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Position)
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	// A best effort batch posts the items within the threshold
+	arg.Mode = BatchBestEffort
+	result, err := store.BatchTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, BatchPartial, result.Batch.Status)
+	require.True(t, result.Items[1].Error.Valid)
+	require.Equal(t, int64(800), result.FromBalance)
+}
+
+func TestPostTxApprovalThreshold(t *testing.T) {
+	store := NewStore(testDB)
+
+	payer := createTestAccountWithThreshold(t, 1000, 300)
+	payee1 := createTestAccountInCurrency(t, 0, util.USD)
+	payee2 := createTestAccountInCurrency(t, 0, util.USD)
+
+	post := func(amount1, amount2 int64) error {
+		_, err := store.PostTx(context.Background(), PostTxParams{
+			Owner: payer.Owner,
+			Legs: []PostingLeg{
+				{AccountID: payer.ID, Amount: -(amount1 + amount2)},
+				{AccountID: payee1.ID, Amount: amount1},
+				{AccountID: payee2.ID, Amount: amount2},
+			},
+		})
+		return err
+	}
+
+	// The debit leg is checked, not what each payee receives
+	require.ErrorIs(t, post(200, 200), ErrApprovalRequired)
+	require.NoError(t, post(100, 200))
+}
+
+func TestHoldTxApprovalThreshold(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountWithThreshold(t, 1000, 300)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+
+	hold := func(amount int64) (HoldTxResult, error) {
+		return store.HoldTx(context.Background(), HoldTxParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+			ExpiresAt:     time.Now().Add(time.Hour),
+		})
+	}
+
+	_, err := hold(301)
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	// A hold within the threshold can be captured without approval
+	held, err := hold(300)
+	require.NoError(t, err)
+	captured, err := store.CaptureTx(context.Background(), CaptureTxParams{TransferID: held.Transfer.ID, Now: time.Now()})
+	require.NoError(t, err)
+	require.Equal(t, int64(700), captured.FromBalance)
+}
+
+func TestRejectTransferRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountInCurrency(t, 1000, util.USD)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	checker := createTestUser(t)
+	request := createTestTransferRequest(t, store, from, to, 400, time.Now().Add(time.Hour))
+
+	rejected, err := store.RejectTransferRequestTx(context.Background(), RejectTransferRequestTxParams{
+		RequestID: request.ID,
+		Rejecter:  checker.Username,
+		Reason:    "Duplicate invoice",
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestRejected, rejected.Status)
+	require.False(t, rejected.TransferID.Valid)
+
+	events, err := store.ListTransferRequestEvents(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "Duplicate invoice", events[1].Note)
+
+	// Nothing was posted
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), account.Balance)
+}
+
+func TestExpireTransferRequestsTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createTestAccountInCurrency(t, 1000, util.USD)
+	to := createTestAccountInCurrency(t, 0, util.USD)
+	checker := createTestUser(t)
+	request := createTestTransferRequest(t, store, from, to, 400, time.Now().Add(-time.Minute))
+
+	// An overdue request can no longer be approved, even before the executor expires it
+	_, err := store.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		RequestID: request.ID,
+		Approver:  checker.Username,
+		Now:       time.Now(),
+	})
+	require.ErrorIs(t, err, ErrTransferRequestExpired)
+
+	expired, err := store.ExpireTransferRequestsTx(context.Background(), ExpireTransferRequestsTxParams{
+		Now:   time.Now(),
+		Limit: 1000,
+	})
+	require.NoError(t, err)
+
+	var found bool
+	for _, r := range expired {
+		if r.ID == request.ID {
+			found = true
+			require.Equal(t, TransferRequestExpired, r.Status)
+		}
+	}
+	require.True(t, found)
+
+	events, err := store.ListTransferRequestEvents(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.False(t, events[1].Actor.Valid)
+}
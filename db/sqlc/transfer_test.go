@@ -3,16 +3,21 @@ package db
 import (
 	"bank/util"
 	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
 
 func createTransferBetweenAcc(t *testing.T, fromAcc, toAcc Account) (int64, CreateTransferParams){
+	amount := util.RandomAmount()
 	arg := CreateTransferParams{
 		FromAccountID: fromAcc.ID,
 		ToAccountID:   toAcc.ID,
-		Amount:        util.RandomAmount(),
+		Amount:        amount,
+		ToAmount:      amount,
+		ExchangeRate:  "1",
 	}
 	traId, err := testQueries.CreateTransfer(context.Background(), arg)
 	require.NoError(t, err)
@@ -102,3 +107,106 @@ func TestListTransfersToAccount(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 5, len(transfers))
 }
+
+func TestListTransfersByOwner(t *testing.T) {
+	acc := createTestAccount(t)
+
+	for i := 0; i < 5; i++ {
+		createTransferBetweenAcc(t, acc, createTestAccount(t))
+		createTransferBetweenAcc(t, createTestAccount(t), acc)
+	}
+
+	params := ListTransfersByOwnerParams{
+		Owner: acc.Owner,
+		Limit: 5,
+	}
+
+	firstPage, err := testQueries.ListTransfersByOwner(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(firstPage))
+
+	params.BeforeID = sql.NullInt64{Int64: firstPage[4].ID, Valid: true}
+	secondPage, err := testQueries.ListTransfersByOwner(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(secondPage))
+
+	// Pages are ordered newest first and do not overlap
+	transfers := append(firstPage, secondPage...)
+	for i, transfer := range transfers {
+		require.True(t, transfer.FromAccountID == acc.ID || transfer.ToAccountID == acc.ID)
+		if i > 0 {
+			require.Less(t, transfer.ID, transfers[i-1].ID)
+		}
+	}
+}
+
+func TestListAccountTransfers(t *testing.T) {
+	acc := createTestAccount(t)
+	counterparty := createTestAccount(t)
+
+	for i := 0; i < 4; i++ {
+		createTransferBetweenAcc(t, acc, counterparty)
+		createTransferBetweenAcc(t, counterparty, acc)
+		createTransferBetweenAcc(t, acc, createTestAccount(t))
+	}
+
+	arg := ListAccountTransfersParams{
+		Direction: "both",
+		AccountID: acc.ID,
+		Limit:     5,
+	}
+	firstPage, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, firstPage, 5)
+	for i := 1; i < len(firstPage); i++ {
+		require.Less(t, firstPage[i].ID, firstPage[i-1].ID)
+	}
+
+	// The next page starts below the last ID of the previous one
+	arg.BeforeID = sql.NullInt64{Int64: firstPage[len(firstPage)-1].ID, Valid: true}
+	arg.Limit = 20
+	secondPage, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, secondPage, 7)
+
+	arg = ListAccountTransfersParams{
+		Direction:      "in",
+		AccountID:      acc.ID,
+		CounterpartyID: sql.NullInt64{Int64: counterparty.ID, Valid: true},
+		Limit:          20,
+	}
+	incoming, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, incoming, 4)
+	for _, transfer := range incoming {
+		require.Equal(t, counterparty.ID, transfer.FromAccountID)
+		require.Equal(t, acc.ID, transfer.ToAccountID)
+	}
+
+	// Pending holds are left out when listing posted transfers
+	hold, err := testQueries.CreateHold(context.Background(), CreateHoldParams{
+		FromAccountID: acc.ID,
+		ToAccountID:   counterparty.ID,
+		Amount:        10,
+		ExpiresAt:     sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	arg = ListAccountTransfersParams{
+		Direction: "both",
+		AccountID: acc.ID,
+		Status:    sql.NullString{String: TransferPosted, Valid: true},
+		Limit:     100,
+	}
+	posted, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, posted, 12)
+	for _, transfer := range posted {
+		require.NotEqual(t, hold.ID, transfer.ID)
+	}
+
+	arg.Status = sql.NullString{String: TransferPending, Valid: true}
+	pending, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, hold.ID, pending[0].ID)
+}
@@ -17,7 +17,7 @@ INSERT INTO users (
     email
 ) VALUES (
   $1, $2, $3, $4 
-) RETURNING username, hashed_password, full_name, email, pasword_changed_at, created_at
+) RETURNING username, hashed_password, full_name, email, pasword_changed_at, created_at, role
 `
 
 type CreateUserParams struct {
@@ -42,12 +42,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PaswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, pasword_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, pasword_changed_at, created_at, role FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -61,6 +62,34 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PaswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+set role = $2
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, pasword_changed_at, created_at, role
+`
+
+type SetUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.Username, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PaswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
 	)
 	return i, err
 }
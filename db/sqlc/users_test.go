@@ -28,6 +28,8 @@ func createTestUser(t *testing.T) User {
 	require.Equal(t, arg.FullName, user.FullName)
 	require.Equal(t, arg.Email, user.Email)
 
+	require.Equal(t, util.RoleCustomer, user.Role)
+
 	require.NotZero(t, user.CreatedAt)
 	require.True(t, user.PaswordChangedAt.IsZero())
 
@@ -52,3 +54,20 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user.CreatedAt, testUser.CreatedAt, time.Second)
 	require.WithinDuration(t, user.PaswordChangedAt, testUser.PaswordChangedAt, time.Second)
 }
+
+func TestSetUserRole(t *testing.T) {
+	user := createTestUser(t)
+
+	arg := SetUserRoleParams{
+		Username: user.Username,
+		Role:     util.RoleBanker,
+	}
+	updated, err := testQueries.SetUserRole(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, updated.Username)
+	require.Equal(t, util.RoleBanker, updated.Role)
+
+	arg.Role = "superuser"
+	_, err = testQueries.SetUserRole(context.Background(), arg)
+	require.Error(t, err)
+}
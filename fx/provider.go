@@ -0,0 +1,84 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not available")
+
+// Supplies exchange rates between currencies
+type RateProvider interface {
+	// Returns the number of to units bought by one from unit
+	Rate(ctx context.Context, from string, to string) (Rate, error)
+}
+
+// Serves a fixed table of rates. The inverse of a configured pair is used when the
+// opposite direction is not configured itself
+type StaticProvider struct {
+	rates map[string]Rate
+}
+
+// Creates a provider from rates keyed by "FROM/TO", e.g. {"USD/EUR": "0.92"}
+func NewStaticProvider(rates map[string]string) (*StaticProvider, error) {
+	provider := &StaticProvider{rates: make(map[string]Rate)}
+	for pair, value := range rates {
+		from, to, ok := strings.Cut(pair, "/")
+		if !ok || from == "" || to == "" || from == to {
+			return nil, fmt.Errorf("invalid currency pair %q, expected FROM/TO", pair)
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate of %s: %w", pair, err)
+		}
+		provider.rates[pairKey(from, to)] = rate
+	}
+	return provider, nil
+}
+
+// Parses FX_RATES entries of the form "FROM/TO:rate" into a static provider
+func NewStaticProviderFromEntries(entries []string) (*StaticProvider, error) {
+	rates := make(map[string]string)
+	for _, entry := range entries {
+		pair, rate, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid FX_RATES entry %q, expected FROM/TO:rate", entry)
+		}
+		rates[pair] = rate
+	}
+	return NewStaticProvider(rates)
+}
+
+// Loads a static table from a JSON object of "FROM/TO" pairs to decimal rates
+func NewFileProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rates map[string]string
+	if err = json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("cannot parse rates file %s: %w", path, err)
+	}
+	return NewStaticProvider(rates)
+}
+
+func (p *StaticProvider) Rate(ctx context.Context, from string, to string) (Rate, error) {
+	if from == to {
+		return One, nil
+	}
+	if rate, ok := p.rates[pairKey(from, to)]; ok {
+		return rate, nil
+	}
+	if rate, ok := p.rates[pairKey(to, from)]; ok {
+		return rate.Inverse(), nil
+	}
+	return Rate{}, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+}
+
+func pairKey(from string, to string) string {
+	return from + "/" + to
+}
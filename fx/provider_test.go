@@ -0,0 +1,58 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticProvider(t *testing.T) {
+	provider, err := NewStaticProviderFromEntries([]string{"USD/EUR:0.8", "EUR/INR:90"})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.80000000", rate.String())
+
+	// The inverse of a configured pair
+	rate, err = provider.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1.25000000", rate.String())
+
+	rate, err = provider.Rate(context.Background(), "CAD", "CAD")
+	require.NoError(t, err)
+	require.Equal(t, One, rate)
+
+	_, err = provider.Rate(context.Background(), "USD", "INR")
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestStaticProviderInvalidEntries(t *testing.T) {
+	for _, entries := range [][]string{
+		{"USD/EUR"},
+		{"USDEUR:0.9"},
+		{"USD/USD:1"},
+		{"USD/EUR:-1"},
+	} {
+		_, err := NewStaticProviderFromEntries(entries)
+		require.Error(t, err, entries)
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"USD/CAD": "1.35", "USD/INR": "83.1"}`), 0o600))
+
+	provider, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "INR")
+	require.NoError(t, err)
+	require.Equal(t, "83.10000000", rate.String())
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Number of decimal places kept in exchange rates, matching the transfers.exchange_rate column
+const rateScale = 8
+
+var ErrInvalidRate = errors.New("exchange rate must be a positive decimal")
+
+// Exchange rate kept as an exact decimal so conversions never go through floats.
+// A rate is the number of destination units bought by one source unit
+type Rate struct {
+	r *big.Rat
+}
+
+// The rate between a currency and itself
+var One = Rate{r: big.NewRat(1, 1)}
+
+// Parses a positive decimal rate such as "1.0825", rounded to 8 decimal places
+func ParseRate(s string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	rounded := roundRat(r)
+	if rounded.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{r: rounded}, nil
+}
+
+// Returns the rate of the opposite direction, rounded to 8 decimal places
+func (rate Rate) Inverse() Rate {
+	return Rate{r: roundRat(new(big.Rat).Inv(rate.rat()))}
+}
+
+// Converts an amount in the source currency to the destination currency,
+// rounding half away from zero
+func (rate Rate) Convert(amount int64) (int64, error) {
+	return rate.ConvertMinor(amount, 0, 0)
+}
+
+// Converts an amount in minor units between currencies with different decimal
+// places, e.g. from cents to yen, rounding half away from zero
+func (rate Rate) ConvertMinor(amount int64, fromMinorUnits int, toMinorUnits int) (int64, error) {
+	shift := new(big.Rat).SetFrac(pow10(toMinorUnits), pow10(fromMinorUnits))
+	product := new(big.Rat).Mul(big.NewRat(amount, 1), rate.rat())
+	product.Mul(product, shift)
+	converted := roundInt(product.Num(), product.Denom())
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converted amount of %d overflows", amount)
+	}
+	return converted.Int64(), nil
+}
+
+// Formats the rate with 8 decimal places
+func (rate Rate) String() string {
+	return rate.rat().FloatString(rateScale)
+}
+
+// The zero Rate behaves as One
+func (rate Rate) rat() *big.Rat {
+	if rate.r == nil {
+		return One.r
+	}
+	return rate.r
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func roundRat(r *big.Rat) *big.Rat {
+	scale := pow10(rateScale)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+	return new(big.Rat).SetFrac(roundInt(scaled.Num(), scaled.Denom()), scale)
+}
+
+// Divides num by denom rounding half away from zero, denom is always positive
+func roundInt(num *big.Int, denom *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, denom, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(denom) >= 0 {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
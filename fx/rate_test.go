@@ -0,0 +1,78 @@
+package fx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("0.92")
+	require.NoError(t, err)
+	require.Equal(t, "0.92000000", rate.String())
+
+	rate, err = ParseRate("1.123456789")
+	require.NoError(t, err)
+	require.Equal(t, "1.12345679", rate.String())
+
+	for _, invalid := range []string{"", "abc", "0", "-1.5", "0.000000001"} {
+		_, err = ParseRate(invalid)
+		require.ErrorIs(t, err, ErrInvalidRate, invalid)
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	rate, err := ParseRate("0.92")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		amount   int64
+		expected int64
+	}{
+		{amount: 100, expected: 92},
+		{amount: 1, expected: 1},
+		{amount: 10, expected: 9},
+		{amount: -10, expected: -9},
+		{amount: 0, expected: 0},
+	}
+	for _, tc := range testCases {
+		converted, err := rate.Convert(tc.amount)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, converted, tc.amount)
+	}
+
+	half, err := ParseRate("0.5")
+	require.NoError(t, err)
+	converted, err := half.Convert(5)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), converted)
+	converted, err = half.Convert(-5)
+	require.NoError(t, err)
+	require.Equal(t, int64(-3), converted)
+
+	huge, err := ParseRate("1000")
+	require.NoError(t, err)
+	_, err = huge.Convert(1 << 60)
+	require.Error(t, err)
+}
+
+func TestRateConvertMinor(t *testing.T) {
+	// 1 USD buys 150.5 JPY, which has no minor units
+	rate, err := ParseRate("150.5")
+	require.NoError(t, err)
+
+	converted, err := rate.ConvertMinor(1234, 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1857), converted)
+
+	converted, err = rate.Inverse().ConvertMinor(1857, 0, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), converted)
+}
+
+func TestRateInverse(t *testing.T) {
+	rate, err := ParseRate("0.8")
+	require.NoError(t, err)
+	require.Equal(t, "1.25000000", rate.Inverse().String())
+	require.Equal(t, "1.00000000", Rate{}.String())
+}
@@ -3,9 +3,15 @@ package main
 import (
 	"bank/api"
 	db "bank/db/sqlc"
+	"bank/reconciliation"
+	"bank/scheduler"
 	"bank/util"
+	"context"
 	"database/sql"
+	"encoding/json"
+	"flag"
 	"log"
+	"os"
 
 	_ "github.com/lib/pq"
 )
@@ -21,14 +27,85 @@ func main() {
 		log.Fatal("cannot connect to the DB:", err)
 	}
 
-	store := db.NewStore(conn)
+	store := db.NewStoreWithConfig(conn, db.TxConfig{
+		MaxRetries: config.TxMaxRetries,
+		RetryDelay: config.TxRetryDelay,
+	})
+
+	// bank reconcile [-json] checks the ledger once and exits
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		os.Exit(reconcile(store, os.Args[2:]))
+	}
+
+	if err = loadCurrencies(store); err != nil {
+		log.Fatal("cannot load currencies:", err)
+	}
+
+	executor := scheduler.NewExecutor(store, scheduler.Config{
+		PollInterval: config.SchedulerInterval,
+		BatchSize:    config.SchedulerBatchSize,
+		RetryDelay:   config.SchedulerRetryDelay,
+		MaxFailures:  config.SchedulerMaxFailures,
+	})
+	go executor.Start(context.Background())
+
 	server, err := api.NewServer(config, store)
 	if err != nil {
 		log.Fatal("cannot create server", err)
 	}
+	go server.StartReconciliation(context.Background())
 
 	err = server.Start(config.ServerAddress)
 	if err != nil {
 		log.Fatal("cannot run server", err)
 	}
 }
+
+// Prints a reconciliation report of the ledger. The exit status is 1 when drift was
+// found so the command can alert from cron
+func reconcile(store db.Store, args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	flags.Parse(args)
+
+	report, err := reconciliation.NewReconciler(store).Run(context.Background())
+	if err != nil {
+		log.Fatal("cannot reconcile ledger:", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal("cannot print report:", err)
+	}
+
+	if !report.Balanced() {
+		return 1
+	}
+	return 0
+}
+
+// Replaces the built-in currencies with the rows of the currencies table
+func loadCurrencies(store db.Store) error {
+	rows, err := store.ListCurrencies(context.Background())
+	if err != nil {
+		return err
+	}
+	currencies := make([]util.Currency, len(rows))
+	for i, row := range rows {
+		currencies[i] = util.Currency{
+			Code:        row.Code,
+			NumericCode: int(row.NumericCode),
+			MinorUnits:  int(row.MinorUnits),
+			Symbol:      row.Symbol,
+			Enabled:     row.Enabled,
+		}
+	}
+	util.SetCurrencies(currencies)
+	return nil
+}
@@ -0,0 +1,71 @@
+package reconciliation
+
+import (
+	db "bank/db/sqlc"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Runs the reconciler on a schedule and keeps the latest report for the admin API
+type Job struct {
+	reconciler *Reconciler
+	interval   time.Duration
+
+	mu     sync.RWMutex
+	latest *Report
+}
+
+// Creates a job running every interval, a zero interval only runs on demand
+func NewJob(store db.Store, interval time.Duration) *Job {
+	return &Job{reconciler: NewReconciler(store), interval: interval}
+}
+
+// Reconciles at once and then every interval until the context is cancelled
+func (job *Job) Start(ctx context.Context) {
+	if job.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := job.Run(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("cannot reconcile ledger:", err)
+		} else if err == nil && !report.Balanced() {
+			log.Printf("ledger reconciliation found %d drifting accounts and %d transfer issues",
+				len(report.AccountDrifts), len(report.TransferIssues))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconciles now and keeps the report as the latest one
+func (job *Job) Run(ctx context.Context) (Report, error) {
+	report, err := job.reconciler.Run(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	job.mu.Lock()
+	job.latest = &report
+	job.mu.Unlock()
+	return report, nil
+}
+
+// Returns the report of the last successful run, false before any run
+func (job *Job) Latest() (Report, bool) {
+	job.mu.RLock()
+	defer job.mu.RUnlock()
+
+	if job.latest == nil {
+		return Report{}, false
+	}
+	return *job.latest, true
+}
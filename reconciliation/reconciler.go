@@ -0,0 +1,95 @@
+package reconciliation
+
+import (
+	db "bank/db/sqlc"
+	"context"
+	"time"
+)
+
+// Most issues of each kind listed in a report when MaxIssues is not set
+const defaultMaxIssues = 1000
+
+// Checks the double-entry invariants of the ledger: the balance of each account is
+// the sum of its entries, and each posted transfer has its debit and credit entries
+type Reconciler struct {
+	store db.Store
+	// Most issues of each kind listed in a report
+	MaxIssues int32
+	now       func() time.Time
+}
+
+func NewReconciler(store db.Store) *Reconciler {
+	return &Reconciler{store: store, MaxIssues: defaultMaxIssues, now: time.Now}
+}
+
+// Scans the whole ledger. The checks only read, they never correct drift
+func (r *Reconciler) Run(ctx context.Context) (Report, error) {
+	report := Report{
+		StartedAt:      r.now(),
+		AccountDrifts:  []AccountDrift{},
+		TransferIssues: []TransferIssue{},
+	}
+	limit := r.MaxIssues
+	if limit <= 0 {
+		limit = defaultMaxIssues
+	}
+
+	drifts, err := r.store.ListAccountDrift(ctx, limit)
+	if err != nil {
+		return report, err
+	}
+	for _, row := range drifts {
+		report.AccountDrifts = append(report.AccountDrifts, AccountDrift{
+			AccountID:    row.ID,
+			Owner:        row.Owner,
+			Currency:     row.Currency,
+			Balance:      row.Balance,
+			EntriesTotal: row.EntriesTotal,
+			TransfersNet: row.TransfersNet,
+			PostingsNet:  row.PostingsNet,
+			BalanceDrift: row.Balance - row.EntriesTotal,
+			EntryDrift:   row.EntriesTotal - row.TransfersNet - row.PostingsNet,
+		})
+	}
+
+	orphaned, err := r.store.ListOrphanedTransfers(ctx, limit)
+	if err != nil {
+		return report, err
+	}
+	for _, row := range orphaned {
+		issue := TransferIssue{
+			TransferID:    row.ID,
+			FromAccountID: row.FromAccountID,
+			ToAccountID:   row.ToAccountID,
+			Amount:        row.Amount,
+			ToAmount:      row.ToAmount,
+		}
+		if !row.HasDebit {
+			issue.Problem = ProblemMissingDebit
+			report.TransferIssues = append(report.TransferIssues, issue)
+		}
+		if !row.HasCredit {
+			issue.Problem = ProblemMissingCredit
+			report.TransferIssues = append(report.TransferIssues, issue)
+		}
+	}
+
+	unbalanced, err := r.store.ListUnbalancedTransfers(ctx, limit)
+	if err != nil {
+		return report, err
+	}
+	for _, row := range unbalanced {
+		report.TransferIssues = append(report.TransferIssues, TransferIssue{
+			TransferID:    row.ID,
+			FromAccountID: row.FromAccountID,
+			ToAccountID:   row.ToAccountID,
+			Amount:        row.Amount,
+			ToAmount:      row.ToAmount,
+			Problem:       ProblemUnbalanced,
+		})
+	}
+
+	report.Truncated = len(drifts) == int(limit) || len(orphaned) == int(limit) || len(unbalanced) == int(limit)
+	report.FinishedAt = r.now()
+	return report, nil
+}
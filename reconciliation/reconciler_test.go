@@ -0,0 +1,114 @@
+package reconciliation
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReconcilerRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ListAccountDrift(gomock.Any(), gomock.Eq(int32(defaultMaxIssues))).
+		Times(1).
+		Return([]db.ListAccountDriftRow{
+			{ID: 1, Owner: "alice", Currency: "USD", Balance: 150, EntriesTotal: 100, TransfersNet: 80, PostingsNet: 10},
+		}, nil)
+	store.EXPECT().
+		ListOrphanedTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListOrphanedTransfersRow{
+			{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, HasDebit: false, HasCredit: false},
+			{ID: 8, FromAccountID: 1, ToAccountID: 2, Amount: 5, ToAmount: 5, HasDebit: true, HasCredit: false},
+		}, nil)
+	store.EXPECT().
+		ListUnbalancedTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListUnbalancedTransfersRow{
+			{ID: 9, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 12},
+		}, nil)
+
+	report, err := NewReconciler(store).Run(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.Balanced())
+	require.False(t, report.Truncated)
+
+	require.Equal(t, []AccountDrift{{
+		AccountID:    1,
+		Owner:        "alice",
+		Currency:     "USD",
+		Balance:      150,
+		EntriesTotal: 100,
+		TransfersNet: 80,
+		PostingsNet:  10,
+		BalanceDrift: 50,
+		EntryDrift:   10,
+	}}, report.AccountDrifts)
+
+	problems := make([]string, len(report.TransferIssues))
+	for i, issue := range report.TransferIssues {
+		problems[i] = issue.Problem
+	}
+	require.Equal(t, []string{ProblemMissingDebit, ProblemMissingCredit, ProblemMissingCredit, ProblemUnbalanced}, problems)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	require.Contains(t, text.String(), "1 drifting accounts")
+	require.Contains(t, text.String(), ProblemUnbalanced)
+}
+
+func TestReconcilerRunBalanced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ListAccountDrift(gomock.Any(), gomock.Eq(int32(2))).Times(1).Return([]db.ListAccountDriftRow{}, nil)
+	store.EXPECT().ListOrphanedTransfers(gomock.Any(), gomock.Eq(int32(2))).Times(1).Return([]db.ListOrphanedTransfersRow{}, nil)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Eq(int32(2))).Times(1).Return([]db.ListUnbalancedTransfersRow{}, nil)
+
+	reconciler := NewReconciler(store)
+	reconciler.MaxIssues = 2
+	report, err := reconciler.Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.Balanced())
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	require.Contains(t, text.String(), "No drift found")
+}
+
+func TestJobKeepsLatestReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().ListAccountDrift(gomock.Any(), gomock.Any()).Times(1).Return(nil, errors.New("connection refused")),
+		store.EXPECT().ListAccountDrift(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListAccountDriftRow{}, nil),
+	)
+	store.EXPECT().ListOrphanedTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListOrphanedTransfersRow{}, nil)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListUnbalancedTransfersRow{}, nil)
+
+	job := NewJob(store, time.Hour)
+	_, ok := job.Latest()
+	require.False(t, ok)
+
+	// A failed run keeps no report
+	_, err := job.Run(context.Background())
+	require.Error(t, err)
+	_, ok = job.Latest()
+	require.False(t, ok)
+
+	report, err := job.Run(context.Background())
+	require.NoError(t, err)
+	latest, ok := job.Latest()
+	require.True(t, ok)
+	require.Equal(t, report, latest)
+}
@@ -0,0 +1,87 @@
+package reconciliation
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Problems found with a transfer
+const (
+	ProblemMissingDebit  = "missing_debit_entry"
+	ProblemMissingCredit = "missing_credit_entry"
+	ProblemUnbalanced    = "unbalanced_amounts"
+)
+
+// An account whose balance, entries and transfers disagree
+type AccountDrift struct {
+	AccountID    int64  `json:"account_id"`
+	Owner        string `json:"owner"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	EntriesTotal int64  `json:"entries_total"`
+	TransfersNet int64  `json:"transfers_net"`
+	// Sum of the entries of multi-leg transactions, which post no transfer
+	PostingsNet int64 `json:"postings_net"`
+	// Balance - EntriesTotal, set when the balance changed without an entry
+	BalanceDrift int64 `json:"balance_drift"`
+	// EntriesTotal - TransfersNet - PostingsNet, set when entries were written without
+	// a transfer or a transfer without its entries
+	EntryDrift int64 `json:"entry_drift"`
+}
+
+type TransferIssue struct {
+	TransferID    int64  `json:"transfer_id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	ToAmount      int64  `json:"to_amount"`
+	Problem       string `json:"problem"`
+}
+
+// Outcome of one scan of the ledger
+type Report struct {
+	StartedAt      time.Time       `json:"started_at"`
+	FinishedAt     time.Time       `json:"finished_at"`
+	AccountDrifts  []AccountDrift  `json:"account_drifts"`
+	TransferIssues []TransferIssue `json:"transfer_issues"`
+	// Set when a check found more issues than MaxIssues, only the first ones are listed
+	Truncated bool `json:"truncated"`
+}
+
+// Reports whether the scan found no issue
+func (report Report) Balanced() bool {
+	return len(report.AccountDrifts) == 0 && len(report.TransferIssues) == 0
+}
+
+// Writes the report as aligned text tables for the reconcile command
+func (report Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Ledger reconciliation %s (%s)\n", report.StartedAt.Format(time.RFC3339), report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+	if report.Balanced() {
+		_, err := fmt.Fprintln(w, "No drift found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(report.AccountDrifts) > 0 {
+		fmt.Fprintf(tw, "\n%d drifting accounts\n", len(report.AccountDrifts))
+		fmt.Fprintln(tw, "ACCOUNT\tOWNER\tCURRENCY\tBALANCE\tENTRIES\tTRANSFERS\tPOSTINGS\tBALANCE DRIFT\tENTRY DRIFT")
+		for _, drift := range report.AccountDrifts {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", drift.AccountID, drift.Owner, drift.Currency,
+				drift.Balance, drift.EntriesTotal, drift.TransfersNet, drift.PostingsNet, drift.BalanceDrift, drift.EntryDrift)
+		}
+	}
+	if len(report.TransferIssues) > 0 {
+		fmt.Fprintf(tw, "\n%d transfer issues\n", len(report.TransferIssues))
+		fmt.Fprintln(tw, "TRANSFER\tFROM\tTO\tAMOUNT\tTO AMOUNT\tPROBLEM")
+		for _, issue := range report.TransferIssues {
+			fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%s\n", issue.TransferID, issue.FromAccountID, issue.ToAccountID,
+				issue.Amount, issue.ToAmount, issue.Problem)
+		}
+	}
+	if report.Truncated {
+		fmt.Fprintln(tw, "\nMore issues were found than listed")
+	}
+	return tw.Flush()
+}
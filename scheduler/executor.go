@@ -0,0 +1,272 @@
+package scheduler
+
+import (
+	db "bank/db/sqlc"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Defaults used for the zero values of Config
+const (
+	defaultPollInterval = 30 * time.Second
+	defaultBatchSize    = 50
+	defaultLease        = 5 * time.Minute
+	defaultRetryDelay   = 15 * time.Minute
+	defaultMaxFailures  = 3
+)
+
+type Config struct {
+	// How often due scheduled transfers are looked up
+	PollInterval time.Duration
+	// Number of scheduled transfers claimed at once
+	BatchSize int32
+	// How long a claimed run may take before another worker retries it
+	Lease time.Duration
+	// Delay before a failed run is retried
+	RetryDelay time.Duration
+	// Consecutive failures after which a scheduled transfer is disabled
+	MaxFailures int32
+}
+
+// Executes due scheduled transfers through Store.TransferTx. Several executors may
+// run against the same database, each claims different rows. A run above the approval
+// threshold of its source account fails like any rejected transfer, nobody is there to
+// request the approval of a co-signer
+type Executor struct {
+	store  db.Store
+	config Config
+	now    func() time.Time
+}
+
+func NewExecutor(store db.Store, config Config) *Executor {
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.Lease <= 0 {
+		config.Lease = defaultLease
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultRetryDelay
+	}
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = defaultMaxFailures
+	}
+	return &Executor{store: store, config: config, now: time.Now}
+}
+
+// Polls for due scheduled transfers, expired holds and expired transfer requests until
+// the context is cancelled
+func (e *Executor) Start(ctx context.Context) {
+	ticker := time.NewTicker(e.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.RunDue(ctx); err != nil && ctx.Err() == nil {
+			log.Println("cannot run scheduled transfers:", err)
+		}
+		if _, err := e.ExpireHolds(ctx); err != nil && ctx.Err() == nil {
+			log.Println("cannot expire holds:", err)
+		}
+		if _, err := e.ExpireTransferRequests(ctx); err != nil && ctx.Err() == nil {
+			log.Println("cannot expire transfer requests:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Claims and executes due scheduled transfers until none are left and
+// returns how many runs were recorded
+func (e *Executor) RunDue(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		claimed, err := e.store.ClaimScheduledTransfersTx(ctx, db.ClaimScheduledTransfersTxParams{
+			Now:   e.now(),
+			Limit: e.config.BatchSize,
+			Lease: e.config.Lease,
+		})
+		if err != nil {
+			return count, err
+		}
+
+		for _, scheduled := range claimed {
+			if err = e.run(ctx, scheduled); err != nil {
+				// The claim expires after the lease and the run is picked again
+				log.Printf("cannot record run of scheduled transfer %d: %v", scheduled.ID, err)
+				continue
+			}
+			count++
+		}
+
+		if len(claimed) < int(e.config.BatchSize) {
+			return count, nil
+		}
+	}
+}
+
+// Releases the funds of holds that expired without being captured and
+// returns how many holds expired
+func (e *Executor) ExpireHolds(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		expired, err := e.store.ExpireHoldsTx(ctx, db.ExpireHoldsTxParams{
+			Now:   e.now(),
+			Limit: e.config.BatchSize,
+		})
+		if err != nil {
+			return count, err
+		}
+		count += len(expired)
+
+		if len(expired) < int(e.config.BatchSize) {
+			return count, nil
+		}
+	}
+}
+
+// Expires the transfer requests no co-signer decided in time and returns how many
+// requests expired
+func (e *Executor) ExpireTransferRequests(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		expired, err := e.store.ExpireTransferRequestsTx(ctx, db.ExpireTransferRequestsTxParams{
+			Now:   e.now(),
+			Limit: e.config.BatchSize,
+		})
+		if err != nil {
+			return count, err
+		}
+		count += len(expired)
+
+		if len(expired) < int(e.config.BatchSize) {
+			return count, nil
+		}
+	}
+}
+
+// Executes one claimed run and records its outcome
+func (e *Executor) run(ctx context.Context, scheduled db.ScheduledTransfer) error {
+	arg := db.FinishScheduledTransferRunTxParams{
+		ScheduledTransfer: scheduled,
+		Enabled:           true,
+	}
+
+	result, err := e.transfer(ctx, scheduled)
+	if err == nil {
+		arg.TransferID = sql.NullInt64{Int64: result.TransferID, Valid: true}
+		arg.NextRunAt, arg.Enabled = nextRun(scheduled, e.now())
+	} else {
+		arg.Error = sql.NullString{String: err.Error(), Valid: true}
+		arg.FailureCount = scheduled.FailureCount + 1
+		arg.NextRunAt = e.now().Add(e.config.RetryDelay)
+		if arg.FailureCount >= e.config.MaxFailures {
+			arg.Enabled = false
+		}
+	}
+
+	_, err = e.store.FinishScheduledTransferRunTx(ctx, arg)
+	return err
+}
+
+// Returns the first run time after now, skipping runs missed while no worker was
+// running. Schedules that never run again are disabled
+func nextRun(scheduled db.ScheduledTransfer, now time.Time) (time.Time, bool) {
+	schedule, err := ParseSchedule(scheduled.Schedule)
+	if err != nil {
+		return scheduled.NextRunAt, false
+	}
+	after := scheduled.NextRunAt
+	if now.After(after) {
+		after = now
+	}
+	next := schedule.Next(after)
+	if next.IsZero() {
+		return scheduled.NextRunAt, false
+	}
+	return next, true
+}
+
+// Moves the money of one run. The idempotency key identifies the run, so a run whose
+// outcome was not recorded before its claim expired does not transfer twice
+func (e *Executor) transfer(ctx context.Context, scheduled db.ScheduledTransfer) (db.TransferTxResult, error) {
+	idempotency := runIdempotencyParams(scheduled)
+
+	stored, err := e.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: idempotency.Username,
+		Key:      idempotency.Key,
+	})
+	if err == nil {
+		var result db.TransferTxResult
+		err = json.Unmarshal(stored.Response, &result)
+		return result, err
+	}
+	if err != sql.ErrNoRows {
+		return db.TransferTxResult{}, err
+	}
+
+	if err = e.validAccounts(ctx, scheduled); err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	return e.store.TransferTx(ctx, db.TransferTxParms{
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+		Description:   fmt.Sprintf("Scheduled transfer %d", scheduled.ID),
+		Idempotency:   idempotency,
+	})
+}
+
+// Applies the checks of POST /transfer again, the accounts may have changed since
+// the transfer was scheduled
+func (e *Executor) validAccounts(ctx context.Context, scheduled db.ScheduledTransfer) error {
+	from, err := e.store.GetAccount(ctx, scheduled.FromAccountID)
+	if err != nil {
+		return fmt.Errorf("cannot load account %d: %w", scheduled.FromAccountID, err)
+	}
+	to, err := e.store.GetAccount(ctx, scheduled.ToAccountID)
+	if err != nil {
+		return fmt.Errorf("cannot load account %d: %w", scheduled.ToAccountID, err)
+	}
+
+	if from.Owner != scheduled.Owner {
+		return errors.New("source account no longer belongs to the owner of the schedule")
+	}
+	for _, account := range []db.Account{from, to} {
+		if account.IsFrozen {
+			return fmt.Errorf("account %d is frozen", account.ID)
+		}
+		if account.IsClearing {
+			return fmt.Errorf("account %d is a clearing account", account.ID)
+		}
+		if account.Currency != scheduled.Currency {
+			return fmt.Errorf("account %d currency mismatch: %s vs %s", account.ID, account.Currency, scheduled.Currency)
+		}
+	}
+	return nil
+}
+
+// Keys of runs are recorded for the system user, apart from the keys owners send in
+// the Idempotency-Key header, so a client key cannot collide with or replay a run
+func runIdempotencyParams(scheduled db.ScheduledTransfer) *db.IdempotencyParams {
+	key := fmt.Sprintf("scheduled-transfer:%d:%d", scheduled.ID, scheduled.NextRunAt.Unix())
+	hash := sha256.Sum256([]byte(key))
+	return &db.IdempotencyParams{
+		Key:         key,
+		Username:    db.SystemUser,
+		RequestHash: hex.EncodeToString(hash[:]),
+	}
+}
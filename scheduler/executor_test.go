@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	mockdb "bank/db/mock"
+	db "bank/db/sqlc"
+	"bank/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExecutorRunDue(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 30, 0, time.UTC)
+	owner := util.RandomOwner()
+
+	from := db.Account{ID: 1, Owner: owner, Currency: util.USD, Balance: 1000}
+	to := db.Account{ID: 2, Owner: util.RandomOwner(), Currency: util.USD}
+	scheduled := db.ScheduledTransfer{
+		ID:            7,
+		Owner:         owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        100,
+		Currency:      util.USD,
+		Schedule:      "0 9 1 * *",
+		NextRunAt:     time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		Enabled:       true,
+	}
+	nextMonth := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		scheduled  db.ScheduledTransfer
+		buildStubs func(store *mockdb.MockStore, scheduled db.ScheduledTransfer)
+		expected   db.FinishScheduledTransferRunTxParams
+	}{
+		{
+			name:      "OK",
+			scheduled: scheduled,
+			buildStubs: func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), from.ID).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), to.ID).Times(1).Return(to, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParms) (db.TransferTxResult, error) {
+						require.Equal(t, scheduled.Amount, arg.Amount)
+						require.NotNil(t, arg.Idempotency)
+						require.Equal(t, db.SystemUser, arg.Idempotency.Username)
+						return db.TransferTxResult{TransferID: 42}, nil
+					})
+			},
+			expected: db.FinishScheduledTransferRunTxParams{
+				ScheduledTransfer: scheduled,
+				TransferID:        sql.NullInt64{Int64: 42, Valid: true},
+				NextRunAt:         nextMonth,
+				Enabled:           true,
+			},
+		},
+		{
+			name:      "AlreadyTransferred",
+			scheduled: scheduled,
+			buildStubs: func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				response, err := json.Marshal(db.TransferTxResult{TransferID: 41})
+				require.NoError(t, err)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{Response: response}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: db.FinishScheduledTransferRunTxParams{
+				ScheduledTransfer: scheduled,
+				TransferID:        sql.NullInt64{Int64: 41, Valid: true},
+				NextRunAt:         nextMonth,
+				Enabled:           true,
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			scheduled: scheduled,
+			buildStubs: func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(from, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			expected: db.FinishScheduledTransferRunTxParams{
+				ScheduledTransfer: scheduled,
+				Error:             sql.NullString{String: db.ErrInsufficientFunds.Error(), Valid: true},
+				NextRunAt:         now.Add(time.Minute),
+				FailureCount:      1,
+				Enabled:           true,
+			},
+		},
+		{
+			name:      "ApprovalRequired",
+			scheduled: scheduled,
+			buildStubs: func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				approvalErr := fmt.Errorf("%w %d", db.ErrApprovalRequired, from.ID)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(from, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, approvalErr)
+				store.EXPECT().CreateTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: db.FinishScheduledTransferRunTxParams{
+				ScheduledTransfer: scheduled,
+				Error:             sql.NullString{String: "amount is above the approval threshold of account 1", Valid: true},
+				NextRunAt:         now.Add(time.Minute),
+				FailureCount:      1,
+				Enabled:           true,
+			},
+		},
+		{
+			name: "DisabledAfterMaxFailures",
+			scheduled: func() db.ScheduledTransfer {
+				failing := scheduled
+				failing.FailureCount = 2
+				return failing
+			}(),
+			buildStubs: func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				frozen := to
+				frozen.IsFrozen = true
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), from.ID).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), to.ID).Times(1).Return(frozen, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: db.FinishScheduledTransferRunTxParams{
+				Error:        sql.NullString{String: "account 2 is frozen", Valid: true},
+				NextRunAt:    now.Add(time.Minute),
+				FailureCount: 3,
+				Enabled:      false,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			store.EXPECT().
+				ClaimScheduledTransfersTx(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return([]db.ScheduledTransfer{tc.scheduled}, nil)
+			tc.buildStubs(store, tc.scheduled)
+
+			expected := tc.expected
+			expected.ScheduledTransfer = tc.scheduled
+			store.EXPECT().
+				FinishScheduledTransferRunTx(gomock.Any(), gomock.Eq(expected)).
+				Times(1)
+
+			executor := NewExecutor(store, Config{BatchSize: 10, RetryDelay: time.Minute, MaxFailures: 3})
+			executor.now = func() time.Time { return now }
+
+			count, err := executor.RunDue(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestExecutorRunDueClaimsUntilDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	batch := []db.ScheduledTransfer{
+		{ID: 1, Owner: util.RandomOwner(), Schedule: "@daily", NextRunAt: time.Now()},
+		{ID: 2, Owner: util.RandomOwner(), Schedule: "@daily", NextRunAt: time.Now()},
+	}
+	response, err := json.Marshal(db.TransferTxResult{TransferID: 1})
+	require.NoError(t, err)
+
+	// A full batch is followed by another claim, a partial batch ends the run
+	gomock.InOrder(
+		store.EXPECT().ClaimScheduledTransfersTx(gomock.Any(), gomock.Any()).Times(1).Return(batch, nil),
+		store.EXPECT().ClaimScheduledTransfersTx(gomock.Any(), gomock.Any()).Times(1).Return([]db.ScheduledTransfer{}, nil),
+	)
+	store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(2).Return(db.IdempotencyKey{Response: response}, nil)
+	store.EXPECT().FinishScheduledTransferRunTx(gomock.Any(), gomock.Any()).Times(2)
+
+	executor := NewExecutor(store, Config{BatchSize: 2})
+	count, err := executor.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestExecutorExpireHolds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	now := time.Now()
+	batch := []db.Transfer{
+		{ID: 1, Status: db.TransferExpired},
+		{ID: 2, Status: db.TransferExpired},
+	}
+
+	gomock.InOrder(
+		store.EXPECT().
+			ExpireHoldsTx(gomock.Any(), gomock.Eq(db.ExpireHoldsTxParams{Now: now, Limit: 2})).
+			Times(1).
+			Return(batch, nil),
+		store.EXPECT().
+			ExpireHoldsTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(batch[:1], nil),
+	)
+
+	executor := NewExecutor(store, Config{BatchSize: 2})
+	executor.now = func() time.Time { return now }
+
+	count, err := executor.ExpireHolds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+}
+
+func TestExecutorExpireTransferRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	now := time.Now()
+	batch := []db.TransferRequest{
+		{ID: 1, Status: db.TransferRequestExpired},
+		{ID: 2, Status: db.TransferRequestExpired},
+	}
+
+	gomock.InOrder(
+		store.EXPECT().
+			ExpireTransferRequestsTx(gomock.Any(), gomock.Eq(db.ExpireTransferRequestsTxParams{Now: now, Limit: 2})).
+			Times(1).
+			Return(batch, nil),
+		store.EXPECT().
+			ExpireTransferRequestsTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return([]db.TransferRequest{}, nil),
+	)
+
+	executor := NewExecutor(store, Config{BatchSize: 2})
+	executor.now = func() time.Time { return now }
+
+	count, err := executor.ExpireTransferRequests(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC) // a Wednesday
+
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "0 9 1 * *", expected: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", expected: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@daily", expected: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expected: time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 8 * * 1-5", expected: time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expected: time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 12 15,30 * *", expected: time.Date(2024, 2, 15, 12, 0, 0, 0, time.UTC)},
+		// With both day fields set either one matches
+		{spec: "0 0 10 * 5", expected: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "@every 168h", expected: from.Add(168 * time.Hour)},
+	}
+	for _, tc := range testCases {
+		schedule, err := ParseSchedule(tc.spec)
+		require.NoError(t, err, tc.spec)
+		require.Equal(t, tc.expected, schedule.Next(from), tc.spec)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 30s",
+		"@every soon",
+		"@sometimes",
+	} {
+		_, err := ParseSchedule(spec)
+		require.ErrorIs(t, err, ErrInvalidSchedule, spec)
+	}
+}
+
+func TestScheduleNeverRuns(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestFirstRun(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	cron, err := ParseSchedule("0 9 1 * *")
+	require.NoError(t, err)
+	require.Equal(t, start, FirstRun(cron, start))
+	require.Equal(t, time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), FirstRun(cron, start.Add(time.Second)))
+
+	interval, err := ParseSchedule("@every 24h")
+	require.NoError(t, err)
+	require.Equal(t, start, FirstRun(interval, start))
+}
@@ -0,0 +1,59 @@
+package statement
+
+import (
+	"bank/util"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writes one row per entry after a header row
+type csvWriter struct {
+	w        *csv.Writer
+	currency string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(s Statement) error {
+	c.currency = s.Account.Currency
+	return c.w.Write([]string{"entry_id", "posted_at", "amount", "balance", "currency", "transfer_id", "description"})
+}
+
+func (c *csvWriter) WriteEntry(e Entry) error {
+	transferID := ""
+	if e.TransferID != 0 {
+		transferID = strconv.FormatInt(e.TransferID, 10)
+	}
+	return c.w.Write([]string{
+		strconv.FormatInt(e.ID, 10),
+		e.PostedAt.UTC().Format(time.RFC3339),
+		util.FormatAmount(e.Amount, c.currency),
+		util.FormatAmount(e.Balance, c.currency),
+		c.currency,
+		transferID,
+		escapeFormula(e.Description),
+	})
+}
+
+// Prefixes text that spreadsheets would evaluate as a formula with a quote, so a
+// description cannot run a formula when the statement is opened
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
@@ -0,0 +1,118 @@
+package statement
+
+import (
+	"bank/util"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// OFX date time format, always written in UTC
+const ofxTimeFormat = "20060102150405"
+
+// Identifies this bank in the BANKID element of exported statements
+const ofxBankID = "BANK"
+
+// Writes an OFX 2.1.1 bank statement response. The ledger balance follows the
+// transaction list, so the closing balance is taken from the statement header
+type ofxWriter struct {
+	w    *bufio.Writer
+	stmt Statement
+}
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	return &ofxWriter{w: bufio.NewWriter(w)}
+}
+
+func (o *ofxWriter) WriteHeader(s Statement) error {
+	o.stmt = s
+	_, err := fmt.Fprintf(o.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<DTSERVER>%s</DTSERVER>
+<LANGUAGE>ENG</LANGUAGE>
+</SONRS>
+</SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>0</TRNUID>
+<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS>
+<CURDEF>%s</CURDEF>
+<BANKACCTFROM>
+<BANKID>%s</BANKID>
+<ACCTID>%d</ACCTID>
+<ACCTTYPE>CHECKING</ACCTTYPE>
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>%s</DTSTART>
+<DTEND>%s</DTEND>
+`,
+		ofxTime(time.Now()),
+		escapeXML(s.Account.Currency),
+		ofxBankID,
+		s.Account.ID,
+		ofxTime(s.Start),
+		ofxTime(s.End),
+	)
+	return err
+}
+
+func (o *ofxWriter) WriteEntry(e Entry) error {
+	trnType := "CREDIT"
+	if e.Amount < 0 {
+		trnType = "DEBIT"
+	}
+	memo := ""
+	if e.Description != "" {
+		memo = "<MEMO>" + escapeXML(e.Description) + "</MEMO>"
+	}
+	_, err := fmt.Fprintf(o.w, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%d</FITID>%s</STMTTRN>\n",
+		trnType,
+		ofxTime(e.PostedAt),
+		util.FormatAmount(e.Amount, o.stmt.Account.Currency),
+		e.ID,
+		memo,
+	)
+	return err
+}
+
+func (o *ofxWriter) Flush() error {
+	return o.w.Flush()
+}
+
+func (o *ofxWriter) Close() error {
+	_, err := fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>%s</BALAMT>
+<DTASOF>%s</DTASOF>
+</LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`,
+		util.FormatAmount(o.stmt.ClosingBalance, o.stmt.Account.Currency),
+		ofxTime(o.stmt.End),
+	)
+	if err != nil {
+		return err
+	}
+	return o.w.Flush()
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format(ofxTimeFormat)
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
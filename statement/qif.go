@@ -0,0 +1,52 @@
+package statement
+
+import (
+	"bank/util"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// QIF dates use the US month first format
+const qifDateFormat = "01/02/2006"
+
+// Writes a QIF bank register, one record per entry terminated by ^
+type qifWriter struct {
+	w        *bufio.Writer
+	currency string
+}
+
+func newQIFWriter(w io.Writer) *qifWriter {
+	return &qifWriter{w: bufio.NewWriter(w)}
+}
+
+func (q *qifWriter) WriteHeader(s Statement) error {
+	q.currency = s.Account.Currency
+	_, err := fmt.Fprintln(q.w, "!Type:Bank")
+	return err
+}
+
+func (q *qifWriter) WriteEntry(e Entry) error {
+	_, err := fmt.Fprintf(q.w, "D%s\nT%s\nN%d\n", e.PostedAt.UTC().Format(qifDateFormat), util.FormatAmount(e.Amount, q.currency), e.ID)
+	if err != nil {
+		return err
+	}
+	if e.Description != "" {
+		// Each QIF field is a single line
+		memo := strings.NewReplacer("\r", " ", "\n", " ").Replace(e.Description)
+		if _, err = fmt.Fprintf(q.w, "M%s\n", memo); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(q.w, "^")
+	return err
+}
+
+func (q *qifWriter) Flush() error {
+	return q.w.Flush()
+}
+
+func (q *qifWriter) Close() error {
+	return q.w.Flush()
+}
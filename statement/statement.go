@@ -0,0 +1,144 @@
+package statement
+
+import (
+	db "bank/db/sqlc"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Export formats selectable with the format query parameter
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+)
+
+// Number of entries read from the DB per query while exporting
+const pageSize = 500
+
+// Describes the account and period of an exported statement
+type Statement struct {
+	Account        db.Account
+	Start          time.Time
+	End            time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+}
+
+// One ledger entry of the statement with the account balance after it
+type Entry struct {
+	ID       int64
+	Amount   int64
+	Balance  int64
+	PostedAt time.Time
+	// Zero for entries posted before entries were linked to their transfer
+	TransferID  int64
+	Description string
+}
+
+// Renders a statement entry by entry so large periods are never held in memory
+type Writer interface {
+	WriteHeader(s Statement) error
+	WriteEntry(e Entry) error
+	// Writes buffered output to the underlying writer
+	Flush() error
+	// Writes the trailer of the document and flushes buffered output
+	Close() error
+}
+
+// Reads the entries of a statement period page by page
+type EntryLister interface {
+	ListAccountEntries(ctx context.Context, arg db.ListAccountEntriesParams) ([]db.ListAccountEntriesRow, error)
+}
+
+// Creates the writer rendering the format to w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatOFX:
+		return newOFXWriter(w), nil
+	case FormatQIF:
+		return newQIFWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported statement format %q", format)
+}
+
+// Returns the Content-Type header value of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatQIF:
+		return "application/qif"
+	}
+	return "application/octet-stream"
+}
+
+// Returns the attachment file name of the statement in the format
+func FileName(s Statement, format string) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s", s.Account.ID, s.Start.Format("20060102"), s.End.Format("20060102"), format)
+}
+
+// Streams the statement period rendered in the format to dst. Output is flushed after
+// every page when dst supports it, so clients receive large statements progressively
+func Export(ctx context.Context, lister EntryLister, s Statement, format string, dst io.Writer) error {
+	w, err := NewWriter(format, dst)
+	if err != nil {
+		return err
+	}
+	if err = w.WriteHeader(s); err != nil {
+		return err
+	}
+
+	// Each page starts from the balance the previous one ended with, so the balance is
+	// never summed from the entries again
+	afterID := sql.NullInt64{}
+	balance := s.OpeningBalance
+	for {
+		rows, err := lister.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+			AccountID:    s.Account.ID,
+			CreatedFrom:  s.Start,
+			CreatedTo:    s.End,
+			AfterID:      afterID,
+			Limit:        pageSize,
+			StartBalance: sql.NullInt64{Int64: balance, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			err = w.WriteEntry(Entry{
+				ID:       row.ID,
+				Amount:   row.Amount,
+				Balance:  row.RunningBalance,
+				PostedAt: row.CreatedAt,
+				// Invalid transfer IDs read as zero
+				TransferID:  row.TransferID.Int64,
+				Description: row.Description,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := dst.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(rows) < pageSize {
+			break
+		}
+		afterID = sql.NullInt64{Int64: rows[len(rows)-1].ID, Valid: true}
+		balance = rows[len(rows)-1].RunningBalance
+	}
+
+	return w.Close()
+}
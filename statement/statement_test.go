@@ -0,0 +1,186 @@
+package statement
+
+import (
+	db "bank/db/sqlc"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Serves the entries in pages the way ListAccountEntries does, running balances
+// continue from the given start balance
+type fakeLister struct {
+	rows  []db.ListAccountEntriesRow
+	calls int
+	// Start balance of every call
+	starts []sql.NullInt64
+}
+
+func (f *fakeLister) ListAccountEntries(ctx context.Context, arg db.ListAccountEntriesParams) ([]db.ListAccountEntriesRow, error) {
+	f.calls++
+	f.starts = append(f.starts, arg.StartBalance)
+	page := []db.ListAccountEntriesRow{}
+	balance := arg.StartBalance.Int64
+	for _, row := range f.rows {
+		if arg.AfterID.Valid && row.ID <= arg.AfterID.Int64 {
+			continue
+		}
+		if len(page) == int(arg.Limit) {
+			break
+		}
+		balance += row.Amount
+		row.RunningBalance = balance
+		page = append(page, row)
+	}
+	return page, nil
+}
+
+func testStatement(n int) (Statement, *fakeLister) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	stmt := Statement{
+		Account:        db.Account{ID: 42, Owner: "owner", Currency: "USD"},
+		Start:          start,
+		End:            start.AddDate(0, 1, 0),
+		OpeningBalance: 1000,
+	}
+
+	lister := &fakeLister{}
+	balance := stmt.OpeningBalance
+	for i := 0; i < n; i++ {
+		amount := int64(10)
+		if i%2 == 1 {
+			amount = -5
+		}
+		balance += amount
+		row := db.ListAccountEntriesRow{
+			ID:             int64(i + 1),
+			AccountID:      stmt.Account.ID,
+			Amount:         amount,
+			CreatedAt:      start.Add(time.Duration(i) * time.Minute),
+			RunningBalance: balance,
+		}
+		// Credits stand for legacy entries without a transfer or description
+		if amount < 0 {
+			row.TransferID = sql.NullInt64{Int64: int64(100 + i), Valid: true}
+			row.Description = "Rent & <utilities>"
+		}
+		lister.rows = append(lister.rows, row)
+	}
+	stmt.ClosingBalance = balance
+	return stmt, lister
+}
+
+func TestExportCSV(t *testing.T) {
+	n := pageSize + 10
+	stmt, lister := testStatement(n)
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), lister, stmt, FormatCSV, &buf))
+	require.Equal(t, 2, lister.calls)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, n+1)
+	require.Equal(t, []string{"entry_id", "posted_at", "amount", "balance", "currency", "transfer_id", "description"}, records[0])
+	require.Equal(t, []string{"1", "2024-05-01T00:00:00Z", "0.10", "10.10", "USD", "", ""}, records[1])
+	require.Equal(t, []string{"2", "2024-05-01T00:01:00Z", "-0.05", "10.05", "USD", "101", "Rent & <utilities>"}, records[2])
+}
+
+func TestExportCarriesBalanceAcrossPages(t *testing.T) {
+	n := pageSize + 10
+	stmt, lister := testStatement(n)
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), lister, stmt, FormatCSV, &buf))
+
+	// The second page starts from the last balance of the first one
+	require.Len(t, lister.starts, 2)
+	require.Equal(t, sql.NullInt64{Int64: stmt.OpeningBalance, Valid: true}, lister.starts[0])
+	require.Equal(t, sql.NullInt64{Int64: lister.rows[pageSize-1].RunningBalance, Valid: true}, lister.starts[1])
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "22.75", records[len(records)-1][3])
+	require.Equal(t, stmt.ClosingBalance, int64(2275))
+}
+
+func TestExportCSVEscapesFormulas(t *testing.T) {
+	stmt, lister := testStatement(0)
+	descriptions := []string{"=HYPERLINK(\"http://x\")", "+1", "-1", "@SUM(A1)", "\tcmd", "\rcmd", "Rent - May", ""}
+	for i, description := range descriptions {
+		lister.rows = append(lister.rows, db.ListAccountEntriesRow{
+			ID:          int64(i + 1),
+			Amount:      1,
+			CreatedAt:   stmt.Start,
+			Description: description,
+		})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), lister, stmt, FormatCSV, &buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, len(descriptions)+1)
+	expected := []string{"'=HYPERLINK(\"http://x\")", "'+1", "'-1", "'@SUM(A1)", "'\tcmd", "'\rcmd", "Rent - May", ""}
+	for i, record := range records[1:] {
+		require.Equal(t, expected[i], record[6])
+	}
+}
+
+func TestExportOFX(t *testing.T) {
+	stmt, lister := testStatement(3)
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), lister, stmt, FormatOFX, &buf))
+
+	var doc struct {
+		Transactions []struct {
+			Type   string `xml:"TRNTYPE"`
+			Posted string `xml:"DTPOSTED"`
+			Amount string `xml:"TRNAMT"`
+			FITID  string `xml:"FITID"`
+			Memo   string `xml:"MEMO"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>STMTTRN"`
+		Currency string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>CURDEF"`
+		Ledger   string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>BALAMT"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, "USD", doc.Currency)
+	require.Equal(t, "10.15", doc.Ledger)
+	require.Len(t, doc.Transactions, 3)
+	require.Equal(t, "DEBIT", doc.Transactions[1].Type)
+	require.Equal(t, "20240501000100", doc.Transactions[1].Posted)
+	require.Equal(t, "-0.05", doc.Transactions[1].Amount)
+	require.Equal(t, "2", doc.Transactions[1].FITID)
+	require.Equal(t, "Rent & <utilities>", doc.Transactions[1].Memo)
+	require.Empty(t, doc.Transactions[0].Memo)
+	require.NotContains(t, buf.String(), "<MEMO></MEMO>")
+}
+
+func TestExportQIF(t *testing.T) {
+	stmt, lister := testStatement(2)
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), lister, stmt, FormatQIF, &buf))
+
+	expected := strings.Join([]string{
+		"!Type:Bank",
+		"D05/01/2024", "T0.10", "N1", "^",
+		"D05/01/2024", "T-0.05", "N2", "MRent & <utilities>", "^",
+		"",
+	}, "\n")
+	require.Equal(t, expected, buf.String())
+}
+
+func TestNewWriterUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("pdf", &bytes.Buffer{})
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// Implemented by makers that sign with asymmetric keys, so other services
+// can verify tokens without holding any secret
+type PublicKeyProvider interface {
+	// Public keys of every key in the ring, in JWK format
+	PublicKeys() []JWK
+}
+
+// JSON Web Key for an Ed25519 public key (RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg,omitempty"`
+}
+
+func newEd25519JWK(keyID string, publicKey ed25519.PublicKey, alg string) JWK {
+	return JWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(publicKey),
+		Kid: keyID,
+		Use: "sig",
+		Alg: alg,
+	}
+}
+
+func sortJWKs(keys []JWK) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+}
+
+// Parses a hex encoded Ed25519 private key, either the 32 byte seed or the 64 byte key
+func parseEd25519PrivateKey(hexKey string) (ed25519.PrivateKey, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("private key must be hex encoded: %w", err)
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		privateKey := ed25519.PrivateKey(key)
+		// Reject keys whose public half does not match the seed
+		if !privateKey.Equal(ed25519.NewKeyFromSeed(privateKey.Seed())) {
+			return nil, fmt.Errorf("malformed Ed25519 private key")
+		}
+		return privateKey, nil
+	}
+	return nil, fmt.Errorf("invalid Ed25519 private key size: must be %d or %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize)
+}
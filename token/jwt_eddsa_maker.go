@@ -0,0 +1,86 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// Signs JWTs with Ed25519 private keys, anyone holding the public keys can verify them
+type EdDSAJWTMaker struct {
+	primaryID   string
+	privateKeys map[string]ed25519.PrivateKey
+}
+
+// Creates an EdDSA JWT maker from a ring of hex encoded Ed25519 private keys
+func NewEdDSAJWTMaker(ring *KeyRing) (Maker, error) {
+	privateKeys := make(map[string]ed25519.PrivateKey)
+	for id, hexKey := range ring.Keys() {
+		privateKey, err := parseEd25519PrivateKey(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		privateKeys[id] = privateKey
+	}
+	return &EdDSAJWTMaker{primaryID: ring.PrimaryID(), privateKeys: privateKeys}, nil
+}
+
+// Create an access or refresh token for a username and duration within a session
+func (m *EdDSAJWTMaker) CreateToken(username string, role string, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, sessionID, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, payload)
+	if m.primaryID != "" {
+		jwtToken.Header["kid"] = m.primaryID
+	}
+	token, err := jwtToken.SignedString(m.privateKeys[m.primaryID])
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
+}
+
+// Verify token if valid or no
+func (m *EdDSAJWTMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		// Only EdDSA is accepted, so a public key can never be used as an HMAC secret
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, ErrInvalidToken
+		}
+		keyID, _ := token.Header["kid"].(string)
+		privateKey, ok := m.privateKeys[keyID]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return privateKey.Public(), nil
+	}
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
+
+func (m *EdDSAJWTMaker) PublicKeys() []JWK {
+	keys := make([]JWK, 0, len(m.privateKeys))
+	for id, privateKey := range m.privateKeys {
+		keys = append(keys, newEd25519JWK(id, privateKey.Public().(ed25519.PublicKey), jwt.SigningMethodEdDSA.Alg()))
+	}
+	sortJWKs(keys)
+	return keys
+}
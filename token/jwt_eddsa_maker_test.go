@@ -0,0 +1,105 @@
+package token
+
+import (
+	"bank/util"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomEd25519Key(t *testing.T) string {
+	seed := make([]byte, ed25519.SeedSize)
+	_, err := rand.Read(seed)
+	require.NoError(t, err)
+	return hex.EncodeToString(seed)
+}
+
+func TestEdDSAJWTMaker(t *testing.T) {
+	ring, err := NewKeyRing("k1", map[string]string{"k1": randomEd25519Key(t)})
+	require.NoError(t, err)
+	maker, err := NewEdDSAJWTMaker(ring)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	sessionID := uuid.New()
+
+	duration := time.Minute
+	issuedAt := time.Now()
+	expireAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, util.RoleBanker, AccessToken, sessionID, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, util.RoleBanker, payload.Role)
+	require.Equal(t, sessionID, payload.SessionID)
+	require.Equal(t, AccessToken, payload.Type)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expireAt, payload.ExpireAt, time.Second)
+
+	// Another service verifies the token with only the published public key
+	keys := maker.(PublicKeyProvider).PublicKeys()
+	require.Len(t, keys, 1)
+	require.Equal(t, "k1", keys[0].Kid)
+	require.Equal(t, "EdDSA", keys[0].Alg)
+
+	publicKey, err := base64.RawURLEncoding.DecodeString(keys[0].X)
+	require.NoError(t, err)
+	parsed, err := jwt.ParseWithClaims(token, &Payload{}, func(token *jwt.Token) (interface{}, error) {
+		require.Equal(t, "k1", token.Header["kid"])
+		return ed25519.PublicKey(publicKey), nil
+	})
+	require.NoError(t, err)
+	require.True(t, parsed.Valid)
+}
+
+func TestExpiredEdDSAJWT(t *testing.T) {
+	maker, err := NewEdDSAJWTMaker(NewSingleKeyRing(randomEd25519Key(t)))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.RoleCustomer, RefreshToken, uuid.Nil, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestEdDSAJWTRejectsHMAC(t *testing.T) {
+	maker, err := NewEdDSAJWTMaker(NewSingleKeyRing(randomEd25519Key(t)))
+	require.NoError(t, err)
+
+	// An HS256 token signed with the public key must not verify
+	publicKey, err := base64.RawURLEncoding.DecodeString(maker.(PublicKeyProvider).PublicKeys()[0].X)
+	require.NoError(t, err)
+	payload, err := NewPayload(util.RandomOwner(), util.RoleCustomer, RefreshToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString(publicKey)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidEd25519Key(t *testing.T) {
+	_, err := NewEdDSAJWTMaker(NewSingleKeyRing(util.RandomString(32)))
+	require.Error(t, err)
+
+	_, err = NewEdDSAJWTMaker(NewSingleKeyRing(hex.EncodeToString([]byte(util.RandomString(16)))))
+	require.Error(t, err)
+}
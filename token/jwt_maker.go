@@ -6,30 +6,48 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
 const minSecretKeySize = 32
 
 type JWTMaker struct {
-	secretKey string
+	primaryID string
+	keys      map[string][]byte
 }
 
 func NewJWTMaker(secretKey string) (Maker, error) {
-	if len(secretKey) < minSecretKeySize {
-		return nil, fmt.Errorf("invalid key size: must be at least %d chars", minSecretKeySize)
+	return NewJWTMakerWithKeyRing(NewSingleKeyRing(secretKey))
+}
+
+// Creates a JWT maker that signs with the primary key of the ring and verifies with any of its keys
+func NewJWTMakerWithKeyRing(ring *KeyRing) (Maker, error) {
+	keys := make(map[string][]byte)
+	for id, secretKey := range ring.Keys() {
+		if len(secretKey) < minSecretKeySize {
+			return nil, fmt.Errorf("invalid key size for key %q: must be at least %d chars", id, minSecretKeySize)
+		}
+		keys[id] = []byte(secretKey)
 	}
-	return &JWTMaker{secretKey: secretKey}, nil
+	return &JWTMaker{primaryID: ring.PrimaryID(), keys: keys}, nil
 }
 
-// Create token for a username and duration
-func (m *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// Create an access or refresh token for a username and duration within a session
+func (m *JWTMaker) CreateToken(username string, role string, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, sessionID, duration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	return jwtToken.SignedString([]byte(m.secretKey))
+	if m.primaryID != "" {
+		jwtToken.Header["kid"] = m.primaryID
+	}
+	token, err := jwtToken.SignedString(m.keys[m.primaryID])
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
 }
 
 // Verify token if valid or no
@@ -40,7 +58,13 @@ func (m *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(m.secretKey), nil
+		// Tokens without a kid were signed before key rotation was enabled
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := m.keys[keyID]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return key, nil
 	}
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
 	if err != nil {
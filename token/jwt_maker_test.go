@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -14,21 +15,26 @@ func TestJwtMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	sessionID := uuid.New()
 
 	duration := time.Minute
 	issuedAt := time.Now()
 	expireAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, util.RoleBanker, AccessToken, sessionID, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, util.RoleBanker, payload.Role)
+	require.Equal(t, sessionID, payload.SessionID)
+	require.Equal(t, AccessToken, payload.Type)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expireAt, payload.ExpireAt, time.Second)
 
@@ -42,11 +48,12 @@ func TestExpiredJWT(t *testing.T) {
 
 	duration := -time.Minute
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, util.RoleCustomer, RefreshToken, uuid.Nil, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token)
 	require.Error(t, err)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
@@ -56,7 +63,7 @@ func TestInvalidJWT(t *testing.T) {
 	username := util.RandomOwner()
 	duration := time.Minute
 
-	payload, err := NewPayload(username, duration)
+	payload, err := NewPayload(username, util.RoleCustomer, RefreshToken, uuid.Nil, duration)
 	require.NoError(t, err)
 
 	claims := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
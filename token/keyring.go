@@ -0,0 +1,73 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Holds every key a maker accepts, indexed by key ID.
+// New tokens are signed with the primary key and carry its ID (JWT kid header or PASETO footer),
+// tokens signed with any other key in the ring keep verifying until that key is retired from the ring
+type KeyRing struct {
+	primaryID string
+	keys      map[string]string
+}
+
+// Creates a key ring. The empty key ID is allowed for tokens issued without a kid
+func NewKeyRing(primaryID string, keys map[string]string) (*KeyRing, error) {
+	if _, ok := keys[primaryID]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the key ring", primaryID)
+	}
+
+	ring := &KeyRing{
+		primaryID: primaryID,
+		keys:      make(map[string]string, len(keys)),
+	}
+	for id, key := range keys {
+		ring.keys[id] = key
+	}
+	return ring, nil
+}
+
+// Creates a key ring holding a single key without an ID
+func NewSingleKeyRing(key string) *KeyRing {
+	return &KeyRing{keys: map[string]string{"": key}}
+}
+
+// ID of the key new tokens are signed with
+func (r *KeyRing) PrimaryID() string {
+	return r.primaryID
+}
+
+// Copy of all keys indexed by key ID
+func (r *KeyRing) Keys() map[string]string {
+	keys := make(map[string]string, len(r.keys))
+	for id, key := range r.keys {
+		keys[id] = key
+	}
+	return keys
+}
+
+// Footer stored in PASETO tokens to identify the key
+type keyFooter struct {
+	KeyID string `json:"kid"`
+}
+
+func encodeKeyFooter(keyID string) []byte {
+	if keyID == "" {
+		return nil
+	}
+	footer, _ := json.Marshal(keyFooter{KeyID: keyID})
+	return footer
+}
+
+func decodeKeyFooter(footer []byte) (string, error) {
+	if len(footer) == 0 {
+		return "", nil
+	}
+	var f keyFooter
+	if err := json.Unmarshal(footer, &f); err != nil {
+		return "", err
+	}
+	return f.KeyID, nil
+}
@@ -0,0 +1,76 @@
+package token
+
+import (
+	"bank/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyRing(t *testing.T) {
+	_, err := NewKeyRing("missing", map[string]string{"k1": util.RandomString(32)})
+	require.Error(t, err)
+
+	ring, err := NewKeyRing("k1", map[string]string{"k1": util.RandomString(32)})
+	require.NoError(t, err)
+	require.Equal(t, "k1", ring.PrimaryID())
+	require.Len(t, ring.Keys(), 1)
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := util.RandomString(32)
+	newKey := util.RandomString(32)
+
+	for _, tokenType := range []string{TypeJWT, TypePaseto} {
+		t.Run(tokenType, func(t *testing.T) {
+			// Tokens issued before rotation have no key ID
+			legacyMaker, err := NewMaker(tokenType, NewSingleKeyRing(oldKey))
+			require.NoError(t, err)
+			legacyToken, _, err := legacyMaker.CreateToken(util.RandomOwner(), util.RoleCustomer, RefreshToken, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+
+			oldRing, err := NewKeyRing("k1", map[string]string{"": oldKey, "k1": oldKey})
+			require.NoError(t, err)
+			oldMaker, err := NewMaker(tokenType, oldRing)
+			require.NoError(t, err)
+			oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.RoleCustomer, RefreshToken, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+
+			// Rotate: k2 becomes primary while k1 and the legacy key still verify
+			rotatedRing, err := NewKeyRing("k2", map[string]string{"": oldKey, "k1": oldKey, "k2": newKey})
+			require.NoError(t, err)
+			rotatedMaker, err := NewMaker(tokenType, rotatedRing)
+			require.NoError(t, err)
+
+			for _, token := range []string{legacyToken, oldToken} {
+				_, err = rotatedMaker.VerifyToken(token)
+				require.NoError(t, err)
+			}
+			newToken, _, err := rotatedMaker.CreateToken(util.RandomOwner(), util.RoleCustomer, RefreshToken, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+			_, err = oldMaker.VerifyToken(newToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+
+			// Retire the old keys
+			retiredRing, err := NewKeyRing("k2", map[string]string{"k2": newKey})
+			require.NoError(t, err)
+			retiredMaker, err := NewMaker(tokenType, retiredRing)
+			require.NoError(t, err)
+
+			_, err = retiredMaker.VerifyToken(newToken)
+			require.NoError(t, err)
+			for _, token := range []string{legacyToken, oldToken} {
+				_, err = retiredMaker.VerifyToken(token)
+				require.EqualError(t, err, ErrInvalidToken.Error())
+			}
+		})
+	}
+}
+
+func TestNewMakerUnsupportedType(t *testing.T) {
+	maker, err := NewMaker("unknown", NewSingleKeyRing(util.RandomString(32)))
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
@@ -1,10 +1,44 @@
 package token
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Maker interface {
-	// Create token for a username and duration
-	CreateToken(username string, duration time.Duration) (string, error)
+	// Create an access or refresh token for a username with its role and duration within a session
+	CreateToken(username string, role string, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
 	// Verify token if valid or no
 	VerifyToken(token string) (*Payload, error)
-}
\ No newline at end of file
+}
+
+// Token backends selectable with the TOKEN_TYPE config
+const (
+	TypeJWT          = "jwt"
+	TypePaseto       = "paseto"
+	TypeJWTEdDSA     = "jwt_eddsa"
+	TypePasetoPublic = "paseto_public"
+)
+
+// Reports whether the token type signs with Ed25519 private keys instead of a shared secret
+func IsAsymmetric(tokenType string) bool {
+	return tokenType == TypeJWTEdDSA || tokenType == TypePasetoPublic
+}
+
+// Creates the maker for the token type, an empty type defaults to JWT.
+// Asymmetric types expect hex encoded Ed25519 private keys in the ring
+func NewMaker(tokenType string, ring *KeyRing) (Maker, error) {
+	switch tokenType {
+	case TypeJWT, "":
+		return NewJWTMakerWithKeyRing(ring)
+	case TypePaseto:
+		return NewPasetoMakerWithKeyRing(ring)
+	case TypeJWTEdDSA:
+		return NewEdDSAJWTMaker(ring)
+	case TypePasetoPublic:
+		return NewPasetoPublicMaker(ring)
+	}
+	return nil, fmt.Errorf("unsupported token type %q", tokenType)
+}
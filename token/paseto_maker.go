@@ -1,6 +1,7 @@
 package token
 
 import (
+	"fmt"
 	"time"
 
 	"aidanwoods.dev/go-paseto"
@@ -8,43 +9,99 @@ import (
 )
 
 type PasetoMaker struct {
-	secretKey paseto.V4SymmetricKey
+	primaryID string
+	keys      map[string]paseto.V4SymmetricKey
 }
 
 func NewPasetoMaker(tokenKey string) (Maker, error) {
-	secretKey, err := paseto.V4SymmetricKeyFromBytes([]byte(tokenKey))
+	return NewPasetoMakerWithKeyRing(NewSingleKeyRing(tokenKey))
+}
+
+// Creates a PASETO maker that encrypts with the primary key of the ring and decrypts with any of its keys
+func NewPasetoMakerWithKeyRing(ring *KeyRing) (Maker, error) {
+	keys := make(map[string]paseto.V4SymmetricKey)
+	for id, tokenKey := range ring.Keys() {
+		secretKey, err := paseto.V4SymmetricKeyFromBytes([]byte(tokenKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		keys[id] = secretKey
+	}
+	return &PasetoMaker{primaryID: ring.PrimaryID(), keys: keys}, nil
+}
+
+// Create an access or refresh token for a username and duration within a session
+func (m *PasetoMaker) CreateToken(username string, role string, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, sessionID, duration)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
-	return &PasetoMaker{secretKey: secretKey}, nil
+	token := newPasetoToken(payload, m.primaryID)
+
+	return token.V4Encrypt(m.keys[m.primaryID], nil), payload, nil
 }
 
-// Create token for a username and duration
-func (m *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// Verify token if valid or no
+func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+	parser := paseto.NewParser()
+	keyID, err := pasetoKeyID(parser, paseto.V4Local, token)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	secretKey, ok := m.keys[keyID]
+	if !ok {
+		return nil, ErrInvalidToken
 	}
+	t, err := parser.ParseV4Local(secretKey, token, nil)
+	if err != nil {
+		return nil, ErrExpiredToken
+	}
+	return pasetoPayload(t)
+}
+
+// Builds a PASETO token holding the payload claims and the key ID footer
+func newPasetoToken(payload *Payload, keyID string) paseto.Token {
 	token := paseto.NewToken()
 	token.SetIssuedAt(payload.IssuedAt)
 	token.SetExpiration(payload.ExpireAt)
 	token.SetString("username", payload.Username)
+	token.SetString("role", payload.Role)
+	token.SetString("type", payload.Type)
 	token.SetString("id", payload.ID.String())
+	token.SetString("session_id", payload.SessionID.String())
 
-	return token.V4Encrypt(m.secretKey, nil), nil
+	token.SetFooter(encodeKeyFooter(keyID))
+	return token
 }
 
-// Verify token if valid or no
-func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
-	parser := paseto.NewParser()
-	t, err := parser.ParseV4Local(m.secretKey, token, nil)
+// Reads the key ID from the footer. The footer is only trusted to pick the key,
+// decrypting or verifying the token authenticates it
+func pasetoKeyID(parser paseto.Parser, protocol paseto.Protocol, token string) (string, error) {
+	footer, err := parser.UnsafeParseFooter(protocol, token)
 	if err != nil {
-		return nil, ErrExpiredToken
+		return "", ErrInvalidToken
+	}
+	keyID, err := decodeKeyFooter(footer)
+	if err != nil {
+		return "", ErrInvalidToken
 	}
+	return keyID, nil
+}
+
+// Reads the payload claims of a verified PASETO token
+func pasetoPayload(t *paseto.Token) (*Payload, error) {
 	username, err := t.GetString("username")
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
+	role, err := t.GetString("role")
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	tokenType, err := t.GetString("type")
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
 	idStr, err := t.GetString("id")
 	if err != nil {
 		return nil, ErrInvalidToken
@@ -53,6 +110,14 @@ func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
+	sessionIDStr, err := t.GetString("session_id")
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
 	expireAt, err := t.GetExpiration()
 	if err != nil {
 		return nil, ErrInvalidToken
@@ -62,10 +127,13 @@ func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrInvalidToken
 	}
 	payload := &Payload{
-		Username: username,
-		ExpireAt: expireAt,
-		IssuedAt: issuedAt,
-		ID:       id,
+		Username:  username,
+		Role:      role,
+		Type:      tokenType,
+		ExpireAt:  expireAt,
+		IssuedAt:  issuedAt,
+		ID:        id,
+		SessionID: sessionID,
 	}
 	return payload, nil
 }
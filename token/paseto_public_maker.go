@@ -0,0 +1,72 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+// Signs v4.public PASETO tokens with Ed25519 private keys, anyone holding the public keys can verify them
+type PasetoPublicMaker struct {
+	primaryID   string
+	privateKeys map[string]paseto.V4AsymmetricSecretKey
+}
+
+// Creates a v4.public PASETO maker from a ring of hex encoded Ed25519 private keys
+func NewPasetoPublicMaker(ring *KeyRing) (Maker, error) {
+	privateKeys := make(map[string]paseto.V4AsymmetricSecretKey)
+	for id, hexKey := range ring.Keys() {
+		privateKey, err := parseEd25519PrivateKey(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		secretKey, err := paseto.NewV4AsymmetricSecretKeyFromEd25519(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		privateKeys[id] = secretKey
+	}
+	return &PasetoPublicMaker{primaryID: ring.PrimaryID(), privateKeys: privateKeys}, nil
+}
+
+// Create an access or refresh token for a username and duration within a session
+func (m *PasetoPublicMaker) CreateToken(username string, role string, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, sessionID, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	token := newPasetoToken(payload, m.primaryID)
+
+	return token.V4Sign(m.privateKeys[m.primaryID], nil), payload, nil
+}
+
+// Verify token if valid or no
+func (m *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	parser := paseto.NewParser()
+	keyID, err := pasetoKeyID(parser, paseto.V4Public, token)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := m.privateKeys[keyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	t, err := parser.ParseV4Public(privateKey.Public(), token, nil)
+	if err != nil {
+		return nil, ErrExpiredToken
+	}
+	return pasetoPayload(t)
+}
+
+func (m *PasetoPublicMaker) PublicKeys() []JWK {
+	keys := make([]JWK, 0, len(m.privateKeys))
+	for id, privateKey := range m.privateKeys {
+		publicKey := ed25519.PublicKey(privateKey.Public().ExportBytes())
+		keys = append(keys, newEd25519JWK(id, publicKey, ""))
+	}
+	sortJWKs(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+package token
+
+import (
+	"bank/util"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoPublicMaker(t *testing.T) {
+	ring, err := NewKeyRing("k1", map[string]string{"k1": randomEd25519Key(t)})
+	require.NoError(t, err)
+	maker, err := NewPasetoPublicMaker(ring)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	sessionID := uuid.New()
+
+	duration := time.Minute
+	issuedAt := time.Now()
+	expireAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, util.RoleBanker, AccessToken, sessionID, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, util.RoleBanker, payload.Role)
+	require.Equal(t, sessionID, payload.SessionID)
+	require.Equal(t, AccessToken, payload.Type)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expireAt, payload.ExpireAt, time.Second)
+
+	// Another service verifies the token with only the published public key
+	keys := maker.(PublicKeyProvider).PublicKeys()
+	require.Len(t, keys, 1)
+	require.Equal(t, "k1", keys[0].Kid)
+
+	publicKeyBytes, err := base64.RawURLEncoding.DecodeString(keys[0].X)
+	require.NoError(t, err)
+	publicKey, err := paseto.NewV4AsymmetricPublicKeyFromBytes(publicKeyBytes)
+	require.NoError(t, err)
+	_, err = paseto.NewParser().ParseV4Public(publicKey, token, nil)
+	require.NoError(t, err)
+}
+
+func TestExpiredPasetoPublic(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(NewSingleKeyRing(randomEd25519Key(t)))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.RoleCustomer, RefreshToken, uuid.Nil, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
@@ -12,23 +12,41 @@ var (
 	ErrInvalidToken = errors.New("token is invalid")
 )
 
+// Purposes of a token. Access tokens authenticate requests, refresh tokens only
+// renew access tokens
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
 type Payload struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"username"`
-	IssuedAt time.Time `json:"issued_at"`
-	ExpireAt time.Time `json:"expire_at"`
+	ID        uuid.UUID `json:"id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Type      string    `json:"type"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpireAt  time.Time `json:"expire_at"`
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+// Creates a payload of the token type belonging to the given session.
+// A zero sessionID starts a new session identified by the payload ID, as done for refresh tokens
+func NewPayload(username string, role string, tokenType string, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
 	id, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
+	if sessionID == uuid.Nil {
+		sessionID = id
+	}
 	payload := &Payload{
-		ID:       id,
-		Username: username,
-		IssuedAt: time.Now(),
-		ExpireAt: time.Now().Add(duration),
+		ID:        id,
+		SessionID: sessionID,
+		Username:  username,
+		Role:      role,
+		Type:      tokenType,
+		IssuedAt:  time.Now(),
+		ExpireAt:  time.Now().Add(duration),
 	}
 	return payload, nil
 }
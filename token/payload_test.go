@@ -25,4 +25,16 @@ func TestPayload(t *testing.T) {
 	require.Equal(t, username, payload.Username)
 	require.Equal(t, issuedAt, payload.IssuedAt)
 	require.Equal(t, expireAt, payload.ExpireAt)
-}
\ No newline at end of file
+}
+
+func TestNewPayloadSession(t *testing.T) {
+	payload, err := NewPayload("testuser", "customer", RefreshToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, payload.SessionID)
+
+	sessionID := uuid.New()
+	payload, err = NewPayload("testuser", "customer", AccessToken, sessionID, time.Minute)
+	require.NoError(t, err)
+	require.NotEqual(t, payload.ID, payload.SessionID)
+	require.Equal(t, sessionID, payload.SessionID)
+}
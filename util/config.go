@@ -9,11 +9,31 @@ import (
 // Stores all the configuration of the application
 // Values are read by Viper from files or environment variables
 type Config struct {
-	DBDriver      string        `mapstructure:"DB_DRIVER"`
-	DBSource      string        `mapstructure:"DB_SOURCE"`
-	ServerAddress string        `mapstructure:"SERVER_ADDRESS"`
-	TokenKey      string        `mapstructure:"TOKEN_KEY"`
-	TokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBDriver                string        `mapstructure:"DB_DRIVER"`
+	DBSource                string        `mapstructure:"DB_SOURCE"`
+	ServerAddress           string        `mapstructure:"SERVER_ADDRESS"`
+	TokenType               string        `mapstructure:"TOKEN_TYPE"`
+	TokenKey                string        `mapstructure:"TOKEN_KEY"`
+	TokenPrivateKey         string        `mapstructure:"TOKEN_PRIVATE_KEY"`
+	TokenKeys               []string      `mapstructure:"TOKEN_KEYS"`
+	TokenPrimaryKeyID       string        `mapstructure:"TOKEN_PRIMARY_KEY_ID"`
+	TokenDuration           time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration    time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RevocationCacheSize     int           `mapstructure:"REVOCATION_CACHE_SIZE"`
+	RevocationCacheTTL      time.Duration `mapstructure:"REVOCATION_CACHE_TTL"`
+	FXEnabled               bool          `mapstructure:"FX_ENABLED"`
+	FXRates                 []string      `mapstructure:"FX_RATES"`
+	FXRatesFile             string        `mapstructure:"FX_RATES_FILE"`
+	FXQuoteDuration         time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+	HoldDuration            time.Duration `mapstructure:"HOLD_DURATION"`
+	TransferRequestDuration time.Duration `mapstructure:"TRANSFER_REQUEST_DURATION"`
+	SchedulerInterval       time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	SchedulerBatchSize      int32         `mapstructure:"SCHEDULER_BATCH_SIZE"`
+	SchedulerRetryDelay     time.Duration `mapstructure:"SCHEDULER_RETRY_DELAY"`
+	SchedulerMaxFailures    int32         `mapstructure:"SCHEDULER_MAX_FAILURES"`
+	ReconcileInterval       time.Duration `mapstructure:"RECONCILE_INTERVAL"`
+	TxMaxRetries            int           `mapstructure:"TX_MAX_RETRIES"`
+	TxRetryDelay            time.Duration `mapstructure:"TX_RETRY_DELAY"`
 }
 
 // Reads the configuration from file or environment
@@ -1,6 +1,11 @@
 package util
 
-// All supported currencies
+import (
+	"sort"
+	"sync"
+)
+
+// Codes of the currencies seeded by the migrations
 const (
 	USD = "USD"
 	CAD = "CAD"
@@ -8,10 +13,91 @@ const (
 	INR = "INR"
 )
 
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, CAD, EUR, INR:
-		return true
+// An ISO 4217 currency. Amounts in the currency are stored as integers in its
+// minor units, e.g. cents for USD
+type Currency struct {
+	Code        string
+	NumericCode int
+	MinorUnits  int
+	Symbol      string
+	Enabled     bool
+}
+
+// Holds the known currencies by code, safe for concurrent use
+type CurrencyRegistry struct {
+	mu         sync.RWMutex
+	currencies map[string]Currency
+}
+
+func NewCurrencyRegistry(currencies []Currency) *CurrencyRegistry {
+	registry := &CurrencyRegistry{}
+	registry.Set(currencies)
+	return registry
+}
+
+// Replaces all currencies of the registry
+func (r *CurrencyRegistry) Set(currencies []Currency) {
+	byCode := make(map[string]Currency, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.currencies = byCode
+}
+
+// Returns the currency with the code, including disabled ones
+func (r *CurrencyRegistry) Lookup(code string) (Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	currency, ok := r.currencies[code]
+	return currency, ok
+}
+
+// Returns the enabled currencies ordered by code
+func (r *CurrencyRegistry) Enabled() []Currency {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	currencies := make([]Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		if currency.Enabled {
+			currencies = append(currencies, currency)
+		}
 	}
-	return false
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
+}
+
+// Used until the currencies table is loaded with SetCurrencies
+var defaultCurrencies = []Currency{
+	{Code: USD, NumericCode: 840, MinorUnits: 2, Symbol: "$", Enabled: true},
+	{Code: CAD, NumericCode: 124, MinorUnits: 2, Symbol: "CA$", Enabled: true},
+	{Code: EUR, NumericCode: 978, MinorUnits: 2, Symbol: "€", Enabled: true},
+	{Code: INR, NumericCode: 356, MinorUnits: 2, Symbol: "₹", Enabled: true},
+}
+
+// Registry behind the package level currency functions
+var Currencies = NewCurrencyRegistry(defaultCurrencies)
+
+// Replaces the currencies of the package registry, typically with the rows of the currencies table
+func SetCurrencies(currencies []Currency) {
+	Currencies.Set(currencies)
+}
+
+func LookupCurrency(code string) (Currency, bool) {
+	return Currencies.Lookup(code)
+}
+
+// Reports whether the currency is known and enabled
+func IsSupportedCurrency(code string) bool {
+	currency, ok := Currencies.Lookup(code)
+	return ok && currency.Enabled
+}
+
+// Returns the decimal places of the currency, unknown currencies have none
+func MinorUnits(code string) int {
+	currency, _ := Currencies.Lookup(code)
+	return currency.MinorUnits
 }
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyRegistry(t *testing.T) {
+	registry := NewCurrencyRegistry([]Currency{
+		{Code: USD, NumericCode: 840, MinorUnits: 2, Symbol: "$", Enabled: true},
+		{Code: "JPY", NumericCode: 392, MinorUnits: 0, Symbol: "¥", Enabled: true},
+		{Code: INR, NumericCode: 356, MinorUnits: 2, Symbol: "₹", Enabled: false},
+	})
+
+	currency, ok := registry.Lookup("JPY")
+	require.True(t, ok)
+	require.Equal(t, 0, currency.MinorUnits)
+
+	// Disabled currencies can still be looked up for existing accounts
+	currency, ok = registry.Lookup(INR)
+	require.True(t, ok)
+	require.False(t, currency.Enabled)
+
+	_, ok = registry.Lookup(EUR)
+	require.False(t, ok)
+
+	enabled := registry.Enabled()
+	require.Len(t, enabled, 2)
+	require.Equal(t, "JPY", enabled[0].Code)
+	require.Equal(t, USD, enabled[1].Code)
+}
+
+func TestIsSupportedCurrency(t *testing.T) {
+	defer SetCurrencies(defaultCurrencies)
+
+	require.True(t, IsSupportedCurrency(USD))
+	require.False(t, IsSupportedCurrency("XYZ"))
+
+	SetCurrencies([]Currency{
+		{Code: USD, MinorUnits: 2, Enabled: true},
+		{Code: EUR, MinorUnits: 2, Enabled: false},
+	})
+	require.True(t, IsSupportedCurrency(USD))
+	require.False(t, IsSupportedCurrency(EUR))
+	require.False(t, IsSupportedCurrency(CAD))
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// An amount in the minor units of its currency. It is formatted with the
+// decimal places of the currency, so 1234 USD is shown as "12.34"
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Formats the amount with the decimal places of the currency
+func (m Money) String() string {
+	return FormatAmount(m.Amount, m.Currency)
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := ParseMoney(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Parses a decimal amount such as "12.34" into the minor units of the currency.
+// More decimal places than the currency has are rejected rather than rounded
+func ParseMoney(s string, currency string) (Money, error) {
+	amount, err := ParseAmount(s, currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func FormatAmount(amount int64, currency string) string {
+	units := MinorUnits(currency)
+	digits := strconv.FormatInt(amount, 10)
+	if units == 0 {
+		return digits
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= units {
+		digits = strings.Repeat("0", units-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+}
+
+func ParseAmount(s string, currency string) (int64, error) {
+	units := MinorUnits(currency)
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	digits := strings.TrimPrefix(whole, "-")
+	if digits == "" || (hasPoint && frac == "") || !isDigits(digits) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(frac) > units {
+		return 0, fmt.Errorf("%w: %s has %d decimal places", ErrInvalidAmount, currency, units)
+	}
+
+	amount, err := strconv.ParseInt(whole+frac+strings.Repeat("0", units-len(frac)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return amount, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
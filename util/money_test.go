@@ -0,0 +1,62 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAmount(t *testing.T) {
+	defer SetCurrencies(defaultCurrencies)
+	SetCurrencies(append([]Currency{{Code: "JPY", MinorUnits: 0, Enabled: true}}, defaultCurrencies...))
+
+	testCases := []struct {
+		amount   int64
+		currency string
+		expected string
+	}{
+		{amount: 1234, currency: USD, expected: "12.34"},
+		{amount: 5, currency: USD, expected: "0.05"},
+		{amount: -5, currency: USD, expected: "-0.05"},
+		{amount: -1234, currency: EUR, expected: "-12.34"},
+		{amount: 0, currency: USD, expected: "0.00"},
+		{amount: 1234, currency: "JPY", expected: "1234"},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, FormatAmount(tc.amount, tc.currency))
+
+		parsed, err := ParseAmount(tc.expected, tc.currency)
+		require.NoError(t, err)
+		require.Equal(t, tc.amount, parsed)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	amount, err := ParseAmount("12.3", USD)
+	require.NoError(t, err)
+	require.Equal(t, int64(1230), amount)
+
+	amount, err = ParseAmount("12", USD)
+	require.NoError(t, err)
+	require.Equal(t, int64(1200), amount)
+
+	for _, s := range []string{"", "-", "12.", ".5", "1.234", "1,00", "1e3", "abc", "99999999999999999999"} {
+		_, err = ParseAmount(s, USD)
+		require.ErrorIs(t, err, ErrInvalidAmount, s)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	money := NewMoney(-1050, EUR)
+
+	data, err := json.Marshal(money)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"-10.50","currency":"EUR"}`, string(data))
+
+	var got Money
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, money, got)
+
+	require.Error(t, json.Unmarshal([]byte(`{"amount":"1.001","currency":"EUR"}`), &got))
+}
@@ -0,0 +1,17 @@
+package util
+
+// All user roles, customers only reach their own accounts while bankers and
+// admins can look up any account
+const (
+	RoleCustomer = "customer"
+	RoleBanker   = "banker"
+	RoleAdmin    = "admin"
+)
+
+func IsSupportedRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleBanker, RoleAdmin:
+		return true
+	}
+	return false
+}